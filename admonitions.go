@@ -0,0 +1,123 @@
+package md2adf
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindAdmonition is the goldmark node kind for admonitions.
+var kindAdmonition = ast.NewNodeKind("Admonition")
+
+// admonition is a Python-Markdown style admonition such as
+// `!!! warning "Title"`. Its children are the blocks of the indented body.
+type admonition struct {
+	ast.BaseBlock
+	kind  string
+	title string
+}
+
+// Kind implements [ast.Node].
+func (n *admonition) Kind() ast.NodeKind { return kindAdmonition }
+
+// Dump implements [ast.Node].
+func (n *admonition) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Kind": n.kind, "Title": n.title}, nil)
+}
+
+// admonitionPattern matches the marker line of an admonition and captures
+// its type and optional quoted title.
+var admonitionPattern = regexp.MustCompile(`^!!![ \t]+([A-Za-z][\w-]*)(?:[ \t]+"([^"]*)")?[ \t]*$`)
+
+// admonitionAlerts maps admonition types to the GitHub alert types whose
+// panel types they share. Unknown types are rendered like "note".
+var admonitionAlerts = map[string]string{
+	"note":      "NOTE",
+	"info":      "NOTE",
+	"abstract":  "NOTE",
+	"tip":       "TIP",
+	"hint":      "TIP",
+	"success":   "TIP",
+	"important": "IMPORTANT",
+	"warning":   "WARNING",
+	"attention": "WARNING",
+	"caution":   "CAUTION",
+	"danger":    "CAUTION",
+	"error":     "CAUTION",
+	"failure":   "CAUTION",
+	"bug":       "CAUTION",
+}
+
+// admonitionIndent is the indentation of an admonition body.
+const admonitionIndent = 4
+
+// admonitionParser parses admonitions: a `!!! type "Title"` line followed
+// by a body indented by four spaces. Blank lines within the body are kept;
+// the first non-blank line indented less ends the admonition.
+type admonitionParser struct{}
+
+func (p *admonitionParser) Trigger() []byte {
+	return []byte{'!'}
+}
+
+func (p *admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	m := admonitionPattern.FindSubmatch(util.TrimRightSpace(line[pos:]))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceToEOL()
+	return &admonition{kind: strings.ToLower(string(m[1])), title: string(m[2])}, parser.HasChildren
+}
+
+func (p *admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, _ := reader.PeekLine()
+	if util.IsBlank(line) {
+		return parser.Continue | parser.HasChildren
+	}
+	if indent, _ := util.IndentWidth(line, reader.LineOffset()); indent < admonitionIndent {
+		return parser.Close
+	}
+	pos, padding := util.IndentPosition(line, reader.LineOffset(), admonitionIndent)
+	reader.AdvanceAndSetPadding(pos, padding)
+	return parser.Continue | parser.HasChildren
+}
+
+func (p *admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *admonitionParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *admonitionParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// convertAdmonition converts an admonition into an ADF "panel" like the
+// GitHub alert its type maps to. The title, if any, becomes a bold first
+// paragraph.
+func (c *converter) convertAdmonition(n *admonition) Node {
+	alert, ok := admonitionAlerts[n.kind]
+	if !ok {
+		alert = "NOTE"
+	}
+	return c.alertPanel(alert, unescapeText([]byte(n.title)), c.convertChildren(n))
+}
+
+// admonitionExtension registers the admonition parser with goldmark.
+type admonitionExtension struct{}
+
+func (e admonitionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&admonitionParser{}, 750)),
+	)
+}
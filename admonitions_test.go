@@ -0,0 +1,76 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_Admonition(t *testing.T) {
+	t.Run("title and multi-paragraph body", func(t *testing.T) {
+		input := "!!! warning \"Breaking change\"\n    Update your *config*.\n\n    Then restart:\n\n    - step one\n\nAfter"
+		content := Convert(input)["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected panel and paragraph, got %d nodes", len(content))
+		}
+		panel := content[0]
+		assertType(t, panel, "panel")
+		if panelType := panel["attrs"].(Node)["panelType"]; panelType != "warning" {
+			t.Errorf("expected panelType 'warning', got %v", panelType)
+		}
+		body := panel["content"].([]Node)
+		if len(body) != 4 {
+			t.Fatalf("expected title, 2 paragraphs and a list, got %d nodes", len(body))
+		}
+		title := body[0]["content"].([]Node)
+		assertText(t, title[0], "Breaking change")
+		assertMarks(t, title[0], "strong")
+		assertText(t, body[1]["content"].([]Node)[1], "config")
+		assertMarks(t, body[1]["content"].([]Node)[1], "em")
+		assertText(t, body[2]["content"].([]Node)[0], "Then restart:")
+		assertType(t, body[3], "bulletList")
+		assertText(t, content[1]["content"].([]Node)[0], "After")
+	})
+
+	t.Run("types", func(t *testing.T) {
+		tests := []struct {
+			kind string
+			want string
+		}{
+			{"note", "info"},
+			{"Tip", "success"},
+			{"danger", "error"},
+			{"important", "note"},
+			{"custom", "info"},
+		}
+		for _, tt := range tests {
+			panel := Convert("!!! " + tt.kind + "\n    Body")["content"].([]Node)[0]
+			assertType(t, panel, "panel")
+			if panelType := panel["attrs"].(Node)["panelType"]; panelType != tt.want {
+				t.Errorf("%s: expected panelType %q, got %v", tt.kind, tt.want, panelType)
+			}
+			// Without a title the body comes first.
+			assertText(t, panel["content"].([]Node)[0]["content"].([]Node)[0], "Body")
+		}
+	})
+
+	t.Run("confluence tip", func(t *testing.T) {
+		panel := Convert("!!! tip\n    Body", WithTarget(TargetConfluence))["content"].([]Node)[0]
+		if panelType := panel["attrs"].(Node)["panelType"]; panelType != "tip" {
+			t.Errorf("expected panelType 'tip', got %v", panelType)
+		}
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		content := Convert("!!! note\n\nOutside")["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected panel and paragraph, got %d nodes", len(content))
+		}
+		assertType(t, content[0], "panel")
+		assertType(t, content[0]["content"].([]Node)[0], "paragraph")
+		assertText(t, content[1]["content"].([]Node)[0], "Outside")
+	})
+
+	t.Run("not an admonition", func(t *testing.T) {
+		for _, md := range []string{"!!!note\n    x", "!!! note \"unclosed\n    x", "Wow !!! note", "\\!!! note\n    x"} {
+			content := Convert(md)["content"].([]Node)
+			assertType(t, content[0], "paragraph")
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package md2adf
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// alertPattern matches the marker line of a GitHub alert such as
+// "[!NOTE]" or "[!WARNING] Custom title".
+var alertPattern = regexp.MustCompile(`(?i)^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\](?:[ \t]+(.*?))?[ \t]*\n?$`)
+
+// alertPanelTypes maps GitHub alert types to ADF panel types.
+var alertPanelTypes = map[string]string{
+	"NOTE":      "info",
+	"TIP":       "success",
+	"IMPORTANT": "note",
+	"WARNING":   "warning",
+	"CAUTION":   "error",
+}
+
+// confluencePanelTypes overrides [alertPanelTypes] for [TargetConfluence].
+var confluencePanelTypes = map[string]string{
+	"TIP": "tip",
+}
+
+// convertAlert converts a blockquote starting with a GitHub alert marker
+// into an ADF "panel" of the matching type. Text following the marker on
+// the same line is a custom title, rendered as a bold first paragraph. It
+// returns nil if the blockquote is not an alert.
+func (c *converter) convertAlert(quote *ast.Blockquote) Node {
+	para, ok := quote.FirstChild().(*ast.Paragraph)
+	if !ok || para.Lines().Len() == 0 {
+		return nil
+	}
+	first := para.Lines().At(0)
+	m := alertPattern.FindSubmatch(first.Value(c.source))
+	if m == nil {
+		return nil
+	}
+
+	// Drop the inline nodes of the marker line; the rest of the paragraph
+	// becomes the panel's body.
+	for child := para.FirstChild(); child != nil; {
+		next := child.NextSibling()
+		para.RemoveChild(para, child)
+		if text, ok := child.(*ast.Text); ok && (text.SoftLineBreak() || text.HardLineBreak()) {
+			break
+		}
+		child = next
+	}
+
+	return c.alertPanel(strings.ToUpper(string(m[1])), unescapeText(m[2]), c.convertChildren(quote))
+}
+
+// alertPanel returns an ADF "panel" for the given GitHub alert type holding
+// body, preceded by title as a bold paragraph unless title is blank.
+func (c *converter) alertPanel(alert, title string, body []Node) Node {
+	var content []Node
+	if title = strings.TrimSpace(title); title != "" {
+		content = append(content, Node{
+			"type":    "paragraph",
+			"content": []Node{newTextNode(title, []Node{{"type": "strong"}})},
+		})
+	}
+	content = append(content, body...)
+	if len(content) == 0 {
+		content = []Node{{"type": "paragraph", "content": []Node{}}}
+	}
+	panelType := alertPanelTypes[alert]
+	if override, ok := confluencePanelTypes[alert]; ok && c.cfg.target == TargetConfluence {
+		panelType = override
+	}
+	return Node{
+		"type":    "panel",
+		"attrs":   Node{"panelType": panelType},
+		"content": content,
+	}
+}
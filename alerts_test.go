@@ -0,0 +1,95 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_Alerts(t *testing.T) {
+	t.Run("bare marker", func(t *testing.T) {
+		panel := Convert("> [!NOTE]\n> Useful information.")["content"].([]Node)[0]
+		assertType(t, panel, "panel")
+		if panelType := panel["attrs"].(Node)["panelType"]; panelType != "info" {
+			t.Errorf("expected panelType 'info', got %v", panelType)
+		}
+		content := panel["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		paraContent := content[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Useful information.")
+	})
+
+	t.Run("custom title", func(t *testing.T) {
+		panel := Convert("> [!WARNING] Breaking change\n> Update your *config*.\n>\n> - step one")["content"].([]Node)[0]
+		assertType(t, panel, "panel")
+		if panelType := panel["attrs"].(Node)["panelType"]; panelType != "warning" {
+			t.Errorf("expected panelType 'warning', got %v", panelType)
+		}
+		content := panel["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(content))
+		}
+		title := content[0]["content"].([]Node)
+		if len(title) != 1 {
+			t.Fatalf("expected 1 title node, got %d", len(title))
+		}
+		assertText(t, title[0], "Breaking change")
+		assertMarks(t, title[0], "strong")
+		body := content[1]["content"].([]Node)
+		assertText(t, body[0], "Update your ")
+		assertText(t, body[1], "config")
+		assertMarks(t, body[1], "em")
+		assertType(t, content[2], "bulletList")
+	})
+
+	t.Run("title only", func(t *testing.T) {
+		panel := Convert("> [!TIP] Just the title")["content"].([]Node)[0]
+		content := panel["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		assertText(t, content[0]["content"].([]Node)[0], "Just the title")
+	})
+
+	t.Run("panel types", func(t *testing.T) {
+		types := map[string]string{
+			"NOTE": "info", "tip": "success", "IMPORTANT": "note", "WARNING": "warning", "CAUTION": "error",
+		}
+		for marker, want := range types {
+			panel := Convert("> [!" + marker + "]\n> body")["content"].([]Node)[0]
+			assertType(t, panel, "panel")
+			if got := panel["attrs"].(Node)["panelType"]; got != want {
+				t.Errorf("%s: expected panelType %q, got %v", marker, want, got)
+			}
+		}
+	})
+
+	t.Run("not an alert", func(t *testing.T) {
+		for _, input := range []string{"> [!UNKNOWN]\n> body", "> Text [!NOTE]", "> [!NOTE]text"} {
+			assertType(t, Convert(input)["content"].([]Node)[0], "blockquote")
+		}
+	})
+}
+
+func TestConvert_AlertTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		target Target
+		want   string
+	}{
+		{"tip for jira", "> [!TIP]\n> Try this.", TargetJira, "success"},
+		{"tip for confluence", "> [!TIP]\n> Try this.", TargetConfluence, "tip"},
+		{"note for confluence", "> [!NOTE]\n> Read this.", TargetConfluence, "info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panel := Convert(tt.input, WithTarget(tt.target))["content"].([]Node)[0]
+			assertType(t, panel, "panel")
+			if panelType := panel["attrs"].(Node)["panelType"]; panelType != tt.want {
+				t.Errorf("expected panelType %q, got %v", tt.want, panelType)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package md2adf
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// alphaListAttr marks lists created from alpha or roman markers, so that a
+// following paragraph of items can extend them.
+var alphaListAttr = []byte("md2adfAlphaList")
+
+// alphaMarkerPattern matches an alpha ("a.", "B)") or roman ("iv.", "XII)")
+// list marker at the start of a line, followed by a space or line end.
+var alphaMarkerPattern = regexp.MustCompile(`^([a-zA-Z]|[ivxlcdm]+|[IVXLCDM]+)[.)](?:[ \t]+|$)`)
+
+// alphaListTransformer turns paragraphs whose first line starts with an
+// alpha or roman list marker into ordered lists. Each line starting with a
+// marker opens a new item; other lines continue the current item. Items
+// separated by blank lines are appended to the preceding alpha list.
+type alphaListTransformer struct{}
+
+func (t *alphaListTransformer) Transform(node *ast.Paragraph, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	lines := node.Lines()
+	first := lines.At(0)
+	marker, start, ok := parseAlphaMarker(first.Value(source))
+	if !ok {
+		return
+	}
+
+	list, _ := node.PreviousSibling().(*ast.List)
+	if list != nil {
+		if _, ok := list.AttributeString(string(alphaListAttr)); !ok {
+			list = nil
+		}
+	}
+	if list == nil {
+		list = ast.NewList(marker)
+		list.Start = start
+		list.SetAttribute(alphaListAttr, true)
+		node.Parent().InsertBefore(node.Parent(), node, list)
+	} else {
+		// Items separated by blank lines make the list loose.
+		list.IsTight = false
+	}
+
+	widths := make([]int, lines.Len())
+	for i := range widths {
+		line := lines.At(i)
+		line = line.TrimLeftSpace(source)
+		widths[i] = alphaMarkerWidth(line.Value(source))
+	}
+	var block ast.Node
+	for i, width := range widths {
+		line := lines.At(i)
+		line = line.TrimLeftSpace(source)
+		if width > 0 {
+			item := ast.NewListItem(width)
+			block = ast.NewTextBlock()
+			item.AppendChild(item, block)
+			list.AppendChild(list, item)
+			line = line.WithStart(line.Start + width)
+		}
+		if i == len(widths)-1 || widths[i+1] > 0 {
+			line = line.TrimRightSpace(source)
+		}
+		// goldmark cannot parse the inline content of empty lines.
+		if !line.IsEmpty() {
+			block.Lines().Append(line)
+		}
+	}
+	node.Parent().RemoveChild(node.Parent(), node)
+}
+
+// parseAlphaMarker parses the alpha or roman list marker at the start of
+// line. It returns the delimiter ('.' or ')') and the ordinal of the marker:
+// 1 for "a" or "i", 2 for "b" or "ii", and so on. Single letters other than
+// "i" are alphabetic; longer markers must be well-formed roman numerals, so
+// words such as "did." are not mistaken for markers.
+func parseAlphaMarker(line []byte) (delim byte, value int, ok bool) {
+	m := alphaMarkerPattern.FindSubmatch(line)
+	if m == nil {
+		return 0, 0, false
+	}
+	delim = m[0][len(m[1])]
+	lower := strings.ToLower(string(m[1]))
+	if len(lower) == 1 && lower != "i" {
+		return delim, int(lower[0]-'a') + 1, true
+	}
+	for i := 0; i < len(lower); i++ {
+		v := romanValues[lower[i]]
+		if i+1 < len(lower) && v < romanValues[lower[i+1]] {
+			value -= v
+		} else {
+			value += v
+		}
+	}
+	if value <= 0 || toRoman(value) != lower {
+		return 0, 0, false
+	}
+	return delim, value, true
+}
+
+// alphaMarkerWidth returns the length of the alpha or roman list marker and
+// the spaces following it at the start of line, or 0 if there is none.
+func alphaMarkerWidth(line []byte) int {
+	if _, _, ok := parseAlphaMarker(line); !ok {
+		return 0
+	}
+	return len(alphaMarkerPattern.Find(line))
+}
+
+// romanValues maps lowercase roman digits to their values.
+var romanValues = map[byte]int{'i': 1, 'v': 5, 'x': 10, 'l': 50, 'c': 100, 'd': 500, 'm': 1000}
+
+// toRoman formats n as a canonical lowercase roman numeral.
+func toRoman(n int) string {
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+		{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+	}
+	var sb strings.Builder
+	for _, r := range numerals {
+		for ; n >= r.value; n -= r.value {
+			sb.WriteString(r.symbol)
+		}
+	}
+	return sb.String()
+}
+
+// alphaListExtension registers the alpha list transformer with goldmark. It
+// runs after the table transformer so that tables are not mistaken for
+// lists.
+type alphaListExtension struct{}
+
+func (e alphaListExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithParagraphTransformers(util.Prioritized(&alphaListTransformer{}, 300)),
+	)
+}
@@ -0,0 +1,70 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_AlphaLists(t *testing.T) {
+	itemTexts := func(t *testing.T, list Node) []string {
+		t.Helper()
+		var texts []string
+		for _, item := range list["content"].([]Node) {
+			assertType(t, item, "listItem")
+			para := item["content"].([]Node)[0]
+			assertType(t, para, "paragraph")
+			texts = append(texts, plainText(para["content"].([]Node)))
+		}
+		return texts
+	}
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string
+	}{
+		{"letters", "a. first\nb. second\nc. third", []string{"first", "second", "third"}},
+		{"upper letters with paren", "A) one\nB) two", []string{"one", "two"}},
+		{"roman", "i. one\nii. two\niii. three\niv. four", []string{"one", "two", "three", "four"}},
+		{"continuation line", "a. first\n   continued\nb. second", []string{"first continued", "second"}},
+		{"blank lines between items", "a. first\n\nb. second\n\nc. third", []string{"first", "second", "third"}},
+		{"inline formatting", "a. **bold** item\nb. [link](https://example.com)", []string{"bold item", "link"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := Convert(tt.markdown, WithAlphaLists())["content"].([]Node)
+			if len(content) != 1 {
+				t.Fatalf("expected 1 node, got %d", len(content))
+			}
+			assertType(t, content[0], "orderedList")
+			got := itemTexts(t, content[0])
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected items %q, got %q", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("item %d: expected %q, got %q", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+
+	t.Run("text before and after", func(t *testing.T) {
+		content := Convert("Steps:\n\na. one\nb. two\n\nDone.", WithAlphaLists())["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+		assertType(t, content[1], "orderedList")
+		assertType(t, content[2], "paragraph")
+	})
+
+	t.Run("not markers", func(t *testing.T) {
+		for _, input := range []string{"e.g. this", "did. nothing", "ab. no", "iiii. bad roman", "a.no space"} {
+			content := Convert(input, WithAlphaLists())["content"].([]Node)
+			assertType(t, content[0], "paragraph")
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		content := Convert("a. first\nb. second")["content"].([]Node)
+		assertType(t, content[0], "paragraph")
+	})
+}
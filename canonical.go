@@ -0,0 +1,67 @@
+package md2adf
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CanonicalJSON serializes doc as indented JSON in a canonical form suitable
+// for golden-file and snapshot tests: object keys are sorted at every level,
+// the marks of every node are listed in a canonical order (link, code,
+// strong, em, strike, underline, subsup, textColor, backgroundColor, then
+// any other types in their original order), and HTML characters such as "<"
+// are not escaped. doc itself is not modified. The output ends with a
+// newline.
+//
+// Output containing random "localId" attrs, such as task and decision
+// lists, is only stable when converted with [WithLocalIDGenerator].
+func CanonicalJSON(doc Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(canonicalValue(doc)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalValue returns a copy of v in which every "marks" array is sorted
+// by [sortMarks]. encoding/json already sorts map keys.
+func canonicalValue(v any) any {
+	switch v := v.(type) {
+	case Node:
+		return canonicalMap(v)
+	case map[string]any:
+		return canonicalMap(v)
+	case []Node:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = canonicalValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = canonicalValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// canonicalMap is [canonicalValue] for a single node or attrs map.
+func canonicalMap(m map[string]any) Node {
+	out := make(Node, len(m))
+	for key, value := range m {
+		out[key] = canonicalValue(value)
+	}
+	if marks, ok := m["marks"].([]Node); ok {
+		sorted := make([]Node, len(marks))
+		for i, mark := range marks {
+			sorted[i] = canonicalMap(mark)
+		}
+		out["marks"] = sortMarks(sorted)
+	}
+	return out
+}
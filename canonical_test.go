@@ -0,0 +1,95 @@
+package md2adf
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+func TestCanonicalJSON(t *testing.T) {
+	t.Run("sorts marks without modifying doc", func(t *testing.T) {
+		marks := []Node{{"type": "strong"}, {"type": "link", "attrs": Node{"href": "https://x.com"}}}
+		doc := Node{"type": "text", "text": "<x>", "marks": marks}
+		got, err := CanonicalJSON(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{
+  "marks": [
+    {
+      "attrs": {
+        "href": "https://x.com"
+      },
+      "type": "link"
+    },
+    {
+      "type": "strong"
+    }
+  ],
+  "text": "<x>",
+  "type": "text"
+}
+`
+		if string(got) != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+		if marks[0]["type"] != "strong" {
+			t.Errorf("expected doc marks to be unchanged, got %v", marks)
+		}
+	})
+
+	t.Run("unsupported value", func(t *testing.T) {
+		if _, err := CanonicalJSON(Node{"type": "doc", "attrs": Node{"f": func() {}}}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+// TestConvert_Golden converts every testdata/golden/*.md file and compares
+// the canonical JSON with the matching .json file. Run with -update to
+// rewrite the golden files after an intended output change.
+func TestConvert_Golden(t *testing.T) {
+	files, err := filepath.Glob("testdata/golden/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden files found")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id := 0
+			doc := Convert(string(src), WithLocalIDGenerator(func() string {
+				id++
+				return fmt.Sprintf("id-%d", id)
+			}))
+			got, err := CanonicalJSON(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(file, ".md") + ".json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("output differs from %s; run with -update if the change is intended\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
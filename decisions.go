@@ -0,0 +1,186 @@
+package md2adf
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindDecisionList is the goldmark node kind for decision lists.
+var kindDecisionList = ast.NewNodeKind("DecisionList")
+
+// decisionList is a list of decisions. Each child is an [ast.TextBlock]
+// holding the text of one decision.
+type decisionList struct {
+	ast.BaseBlock
+	closed bool
+}
+
+// Kind implements [ast.Node].
+func (n *decisionList) Kind() ast.NodeKind { return kindDecisionList }
+
+// Dump implements [ast.Node].
+func (n *decisionList) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+var (
+	// decisionMarker starts a decision line, as in "<> Use PostgreSQL".
+	decisionMarker = []byte("<>")
+	// decisionFenceOpen and decisionFenceClose delimit a decision block.
+	decisionFenceOpen  = []byte(":::decision")
+	decisionFenceClose = []byte(":::")
+)
+
+// decisionMarkerWidth returns the length of the "<>" marker and the spaces
+// following it at the start of line, or 0 if there is none.
+func decisionMarkerWidth(line []byte) int {
+	if !bytes.HasPrefix(line, decisionMarker) {
+		return 0
+	}
+	width := len(decisionMarker)
+	if width < len(line) && !util.IsSpace(line[width]) {
+		return 0
+	}
+	for width < len(line) && (line[width] == ' ' || line[width] == '\t') {
+		width++
+	}
+	return width
+}
+
+// newDecisionItem returns a text block holding line without leading
+// whitespace, an optional "<>" marker, and trailing whitespace.
+func newDecisionItem(line text.Segment, source []byte) *ast.TextBlock {
+	line = line.TrimLeftSpace(source)
+	line = line.WithStart(line.Start + decisionMarkerWidth(line.Value(source)))
+	block := ast.NewTextBlock()
+	if line = line.TrimRightSpace(source); !line.IsEmpty() {
+		block.Lines().Append(line)
+	}
+	return block
+}
+
+// decisionTransformer turns paragraphs whose first line starts with "<> "
+// into decision lists. Each line starting with the marker opens a new
+// decision; other lines continue the current one.
+type decisionTransformer struct{}
+
+func (t *decisionTransformer) Transform(node *ast.Paragraph, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	lines := node.Lines()
+	first := lines.At(0)
+	if decisionMarkerWidth(first.Value(source)) == 0 {
+		return
+	}
+
+	list := &decisionList{}
+	var block *ast.TextBlock
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		line = line.TrimLeftSpace(source)
+		if width := decisionMarkerWidth(line.Value(source)); width > 0 {
+			block = ast.NewTextBlock()
+			list.AppendChild(list, block)
+			line = line.WithStart(line.Start + width)
+		}
+		if i == lines.Len()-1 {
+			line = line.TrimRightSpace(source)
+		} else if next := lines.At(i + 1); decisionMarkerWidth(util.TrimLeftSpace(next.Value(source))) > 0 {
+			line = line.TrimRightSpace(source)
+		}
+		// goldmark cannot parse the inline content of empty lines.
+		if !line.IsEmpty() {
+			block.Lines().Append(line)
+		}
+	}
+	node.Parent().ReplaceChild(node.Parent(), node, list)
+}
+
+// decisionBlockParser parses decision blocks fenced by a ":::decision" line
+// and a ":::" line. Every non-blank line between them is one decision; a
+// leading "<>" marker is optional. An unclosed block runs to the end of
+// its container.
+type decisionBlockParser struct{}
+
+func (p *decisionBlockParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+func (p *decisionBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.Equal(util.TrimRightSpace(line[pos:]), decisionFenceOpen) {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceToEOL()
+	return &decisionList{}, parser.NoChildren
+}
+
+func (p *decisionBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	list := node.(*decisionList)
+	if list.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if trimmed := util.TrimLeftSpace(util.TrimRightSpace(line)); bytes.Equal(trimmed, decisionFenceClose) {
+		reader.AdvanceToEOL()
+		list.closed = true
+		return parser.Close
+	} else if len(trimmed) > 0 {
+		list.AppendChild(list, newDecisionItem(segment, reader.Source()))
+	}
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *decisionBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *decisionBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *decisionBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// convertDecisionList converts a decision list into an ADF "decisionList"
+// whose "decisionItem" children are all in the "DECIDED" state. Lists
+// without decisions are dropped, as ADF requires at least one item.
+func (c *converter) convertDecisionList(list *decisionList) Node {
+	if !list.HasChildren() {
+		return nil
+	}
+	listID := c.localID()
+	content := make([]Node, 0, list.ChildCount())
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		decision := Node{
+			"type":  "decisionItem",
+			"attrs": Node{"localId": c.localID(), "state": "DECIDED"},
+		}
+		if inline := c.convertInlineChildren(item, nil); len(inline) > 0 {
+			decision["content"] = inline
+		}
+		content = append(content, decision)
+	}
+	return Node{
+		"type":    "decisionList",
+		"attrs":   Node{"localId": listID},
+		"content": content,
+	}
+}
+
+// decisionExtension registers the decision block parser and the "<>"
+// paragraph transformer with goldmark. The transformer runs after the table
+// transformer so that tables are not mistaken for decisions.
+type decisionExtension struct{}
+
+func (e decisionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&decisionBlockParser{}, 750)),
+		parser.WithParagraphTransformers(util.Prioritized(&decisionTransformer{}, 300)),
+	)
+}
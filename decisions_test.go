@@ -0,0 +1,95 @@
+package md2adf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConvert_DecisionList(t *testing.T) {
+	assertDecisions := func(t *testing.T, list Node, want ...string) {
+		t.Helper()
+		assertType(t, list, "decisionList")
+		if list["attrs"].(Node)["localId"] == "" {
+			t.Error("expected a localId on the decision list")
+		}
+		items := list["content"].([]Node)
+		if len(items) != len(want) {
+			t.Fatalf("expected %d decisions, got %d", len(want), len(items))
+		}
+		for i, item := range items {
+			assertType(t, item, "decisionItem")
+			attrs := item["attrs"].(Node)
+			if attrs["state"] != "DECIDED" {
+				t.Errorf("expected state 'DECIDED', got %v", attrs["state"])
+			}
+			if attrs["localId"] == "" {
+				t.Error("expected a localId on the decision item")
+			}
+			if text := plainText(item["content"].([]Node)); text != want[i] {
+				t.Errorf("decision %d: expected %q, got %q", i, want[i], text)
+			}
+		}
+	}
+
+	t.Run("marker lines", func(t *testing.T) {
+		content := Convert("<> Use PostgreSQL\n<> Ship on **Friday** morning\n  after review\n\nNext steps.")["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(content))
+		}
+		assertDecisions(t, content[0], "Use PostgreSQL", "Ship on Friday morning after review")
+		assertMarks(t, content[0]["content"].([]Node)[1]["content"].([]Node)[1], "strong")
+		assertType(t, content[1], "paragraph")
+	})
+
+	t.Run("fenced block", func(t *testing.T) {
+		content := Convert(":::decision\nUse PostgreSQL\n\n<> Ship on Friday\n:::\nNext steps.")["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(content))
+		}
+		assertDecisions(t, content[0], "Use PostgreSQL", "Ship on Friday")
+		assertType(t, content[1], "paragraph")
+	})
+
+	t.Run("local ids", func(t *testing.T) {
+		next := 0
+		counter := func() string {
+			next++
+			return fmt.Sprintf("id-%d", next)
+		}
+		list := Convert("<> a\n<> b", WithLocalIDGenerator(counter))["content"].([]Node)[0]
+		if id := list["attrs"].(Node)["localId"]; id != "id-1" {
+			t.Errorf("expected list localId 'id-1', got %v", id)
+		}
+		for i, item := range list["content"].([]Node) {
+			if id, want := item["attrs"].(Node)["localId"], fmt.Sprintf("id-%d", i+2); id != want {
+				t.Errorf("expected item localId %q, got %v", want, id)
+			}
+		}
+	})
+
+	t.Run("not decisions", func(t *testing.T) {
+		for _, input := range []string{"a <> b", "<>tight", "text\n<> later line"} {
+			content := Convert(input)["content"].([]Node)
+			if len(content) != 1 {
+				t.Fatalf("%q: expected 1 node, got %d", input, len(content))
+			}
+			assertType(t, content[0], "paragraph")
+		}
+	})
+
+	t.Run("escaped marker", func(t *testing.T) {
+		content := Convert(`\<> keep me`)["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+		assertText(t, content[0]["content"].([]Node)[0], "<> keep me")
+	})
+
+	t.Run("empty block", func(t *testing.T) {
+		content := Convert(":::decision\n:::")["content"].([]Node)
+		if len(content) != 0 {
+			t.Errorf("expected no nodes, got %d", len(content))
+		}
+	})
+}
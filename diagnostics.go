@@ -0,0 +1,113 @@
+package md2adf
+
+import (
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// Diagnostic describes Markdown content that was dropped or degraded during
+// conversion, such as skipped raw HTML or block types ADF cannot represent.
+type Diagnostic struct {
+	// Message is a human-readable description of what happened.
+	Message string
+	// Line and Column give the approximate 1-based source position of the
+	// affected content. Both are 0 if the position is unknown.
+	Line   int
+	Column int
+}
+
+// String formats the diagnostic as "line:column: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+}
+
+// ConvertWithDiagnostics is like [Convert] but additionally returns a
+// [Diagnostic] for every piece of content that was dropped or degraded, in
+// the order it was encountered. This helps explain why the resulting Jira or
+// Confluence content does not match the Markdown source. With
+// [FrontmatterStrip], frontmatter values that [ConvertFileWithFrontmatter]
+// can only return as raw text are reported as well.
+func ConvertWithDiagnostics(markdown string, opts ...Option) (Node, []Diagnostic) {
+	c := newConverter([]byte(markdown), opts)
+	c.collectDiagnostics = true
+	if c.cfg.frontmatter == FrontmatterStrip {
+		_, _, c.diagnostics, _ = parseFrontmatter(markdown)
+	}
+	doc := c.convertDocument()
+	return doc, c.diagnostics
+}
+
+// warn records a diagnostic for n if diagnostics are being collected.
+func (c *converter) warn(n ast.Node, format string, args ...any) {
+	if !c.collectDiagnostics {
+		return
+	}
+	d := Diagnostic{Message: fmt.Sprintf(format, args...)}
+	d.Line, d.Column = c.position(n)
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// position returns the approximate 1-based line and column at which n
+// starts, or zeros if the position is unknown.
+func (c *converter) position(n ast.Node) (line, column int) {
+	if offset := c.nodeOffset(n); offset >= 0 {
+		line = c.lineAt(offset)
+		return line, offset - c.lineOffset(line) + 1
+	}
+	if n.Parent() != nil && n.Parent().Kind() == ast.KindDocument {
+		// Top-level blocks without content, such as an empty heading, have
+		// no recorded position; locate them like source mapping does.
+		if line, _, ok := c.sourceLines(n); ok {
+			text := c.line(line)
+			return line, len(text) - len(util.TrimLeftSpace(text)) + 1
+		}
+	}
+	return 0, 0
+}
+
+// nodeOffset returns the byte offset in the source at which n starts, or -1
+// if goldmark did not record a position for n or any of its descendants.
+func (c *converter) nodeOffset(n ast.Node) int {
+	start, _, ok := c.nodeRange(n)
+	if !ok {
+		return -1
+	}
+	return start
+}
+
+// nodeRange returns the byte range of the source covered by n and its
+// descendants. ok is false if goldmark did not record any position.
+func (c *converter) nodeRange(n ast.Node) (start, stop int, ok bool) {
+	include := func(s, e int) {
+		if !ok || s < start {
+			start = s
+		}
+		if !ok || e > stop {
+			stop = e
+		}
+		ok = true
+	}
+
+	switch node := n.(type) {
+	case *ast.Text:
+		include(node.Segment.Start, node.Segment.Stop)
+	case *ast.RawHTML:
+		for i := 0; i < node.Segments.Len(); i++ {
+			include(node.Segments.At(i).Start, node.Segments.At(i).Stop)
+		}
+	}
+	if n.Type() == ast.TypeBlock {
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			include(lines.At(i).Start, lines.At(i).Stop)
+		}
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if s, e, childOK := c.nodeRange(child); childOK {
+			include(s, e)
+		}
+	}
+	return start, stop, ok
+}
@@ -0,0 +1,153 @@
+package md2adf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestConvertWithDiagnostics_RawHTML(t *testing.T) {
+	doc, diags := ConvertWithDiagnostics("Hello\n\nSome <span>inline</span> html\n\n<div>\nblock\n</div>\n")
+
+	assertType(t, doc, "doc")
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %d: %v", len(diags), diags)
+	}
+
+	want := []Diagnostic{
+		{Message: `inline raw HTML "<span>" skipped`, Line: 3, Column: 6},
+		{Message: `inline raw HTML "</span>" skipped`, Line: 3, Column: 18},
+		{Message: "raw HTML block skipped", Line: 5, Column: 1},
+	}
+	for i, d := range diags {
+		if d != want[i] {
+			t.Errorf("diagnostic %d: expected %v, got %v", i, want[i], d)
+		}
+	}
+}
+
+func TestConvertWithDiagnostics_Clean(t *testing.T) {
+	doc, diags := ConvertWithDiagnostics("# Title\n\n- a\n- b\n\n<table><tr><td>x</td></tr></table>")
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+
+	want := Convert("# Title\n\n- a\n- b\n\n<table><tr><td>x</td></tr></table>")
+	if len(doc["content"].([]Node)) != len(want["content"].([]Node)) {
+		t.Errorf("expected output to match Convert")
+	}
+}
+
+func TestConvertWithDiagnostics_KbdNotReported(t *testing.T) {
+	_, diags := ConvertWithDiagnostics("Press <kbd>Esc</kbd>", WithKbdAsCode())
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+// unknownBlock is a block type the converter has no explicit case for.
+type unknownBlock struct {
+	ast.BaseBlock
+}
+
+var kindUnknownBlock = ast.NewNodeKind("UnknownBlock")
+
+func (n *unknownBlock) Kind() ast.NodeKind { return kindUnknownBlock }
+
+func (n *unknownBlock) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+func TestConvertWithDiagnostics_UnknownBlock(t *testing.T) {
+	source := []byte("first\n\nsecond")
+	c := newConverter(source, nil)
+	c.collectDiagnostics = true
+
+	doc := c.parse()
+	block := &unknownBlock{}
+	for doc.FirstChild() != nil {
+		child := doc.FirstChild()
+		doc.RemoveChild(doc, child)
+		block.AppendChild(block, child)
+	}
+
+	node := c.convertNode(block)
+	assertType(t, node, "paragraph")
+	assertText(t, node["content"].([]Node)[0], "first")
+
+	if len(c.diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(c.diagnostics), c.diagnostics)
+	}
+	want := Diagnostic{Message: "unsupported UnknownBlock block: kept first of 2 children", Line: 1, Column: 1}
+	if c.diagnostics[0] != want {
+		t.Errorf("expected %v, got %v", want, c.diagnostics[0])
+	}
+
+	if c.convertNode(&unknownBlock{}) != nil {
+		t.Error("expected empty unknown block to be dropped")
+	}
+	if len(c.diagnostics) != 2 || c.diagnostics[1].Message != "unsupported UnknownBlock node dropped" {
+		t.Errorf("expected drop diagnostic, got %v", c.diagnostics)
+	}
+}
+
+func TestConvert_UnknownAsText(t *testing.T) {
+	source := []byte("first **bold**\n\nsecond")
+	c := newConverter(source, []Option{WithUnknownAsText()})
+
+	doc := c.parse()
+	block := &unknownBlock{}
+	for doc.FirstChild() != nil {
+		child := doc.FirstChild()
+		doc.RemoveChild(doc, child)
+		block.AppendChild(block, child)
+	}
+
+	node := c.convertNode(block)
+	assertType(t, node, "paragraph")
+	content := node["content"].([]Node)
+	if len(content) != 4 {
+		t.Fatalf("expected text, 2 hardBreaks, text; got %d nodes", len(content))
+	}
+	assertText(t, content[0], "first **bold**")
+	assertType(t, content[1], "hardBreak")
+	assertType(t, content[2], "hardBreak")
+	assertText(t, content[3], "second")
+}
+
+func TestConvertWithDiagnostics_IndentedCode(t *testing.T) {
+	input := "Intro\n\n    indented code\n    more\n\n```go\nfenced code\n```"
+	doc, diags := ConvertWithDiagnostics(input)
+
+	// Both kinds of code block are valid; only the fenced one has a language.
+	blocks := collectNodes(doc, "codeBlock")
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 codeBlocks, got %d", len(blocks))
+	}
+	if _, ok := blocks[0]["attrs"]; ok {
+		t.Errorf("expected no attrs on indented code block, got %v", blocks[0]["attrs"])
+	}
+	assertText(t, blocks[0]["content"].([]Node)[0], "indented code\nmore")
+	if lang := blocks[1]["attrs"].(Node)["language"]; lang != "go" {
+		t.Errorf("expected language go on fenced code block, got %v", lang)
+	}
+
+	want := Diagnostic{Message: "indented code block converted without language", Line: 3, Column: 5}
+	if len(diags) != 1 || diags[0] != want {
+		t.Errorf("expected %v, got %v", want, diags)
+	}
+}
+
+func TestConvertWithDiagnostics_ManyEmptyHeadings(t *testing.T) {
+	// Positions of blocks goldmark does not locate come from a shared line
+	// table; this used to grow with the cube of the input.
+	const n = 20000
+	_, diags := ConvertWithDiagnostics(strings.Repeat("#\n\n", n))
+	if len(diags) != n {
+		t.Fatalf("expected %d diagnostics, got %d", n, len(diags))
+	}
+	for _, i := range []int{0, 1, n - 1} {
+		if d := diags[i]; d.Line != 2*i+1 || d.Column != 1 {
+			t.Errorf("diagnostic %d: expected position %d:1, got %d:%d", i, 2*i+1, d.Line, d.Column)
+		}
+	}
+}
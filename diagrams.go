@@ -0,0 +1,64 @@
+package md2adf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"strings"
+)
+
+// diagramNames maps the code block languages treated as diagrams to their
+// display names.
+var diagramNames = map[string]string{
+	"mermaid":  "Mermaid",
+	"plantuml": "PlantUML",
+}
+
+// convertDiagram renders a diagram code block according to the configured
+// [DiagramMode]. It returns nil when lang is not a diagram language or the
+// block should stay a plain code block.
+func (c *converter) convertDiagram(lang, code string) Node {
+	name, ok := diagramNames[strings.ToLower(lang)]
+	if !ok {
+		return nil
+	}
+	switch c.cfg.diagramMode {
+	case DiagramPanel:
+		return Node{
+			"type":  "panel",
+			"attrs": Node{"panelType": "info"},
+			"content": []Node{
+				{
+					"type":    "paragraph",
+					"content": []Node{newTextNode(name+" diagram omitted; source below.", nil)},
+				},
+				{
+					"type":    "codeBlock",
+					"attrs":   Node{"language": lang},
+					"content": codeBlockContent(code),
+				},
+			},
+		}
+	case DiagramLink:
+		if c.cfg.diagramRenderURL == "" {
+			return nil
+		}
+		href := c.cfg.diagramRenderURL + "/" + strings.ToLower(lang) + "/svg/" + krokiEncode(code)
+		linkMark := Node{"type": "link", "attrs": Node{"href": href}}
+		return Node{
+			"type":    "paragraph",
+			"content": []Node{newTextNode("View "+name+" diagram", []Node{linkMark})},
+		}
+	}
+	return nil
+}
+
+// krokiEncode deflates source with zlib and encodes it as base64url, the
+// format expected by the Kroki GET API.
+func krokiEncode(source string) string {
+	var buf bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	_, _ = w.Write([]byte(source))
+	_ = w.Close()
+	return base64.URLEncoding.EncodeToString(buf.Bytes())
+}
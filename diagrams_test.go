@@ -0,0 +1,75 @@
+package md2adf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConvert_DiagramMode(t *testing.T) {
+	const input = "```mermaid\ngraph TD\n  A --> B\n```"
+
+	t.Run("code by default", func(t *testing.T) {
+		block := Convert(input)["content"].([]Node)[0]
+		assertType(t, block, "codeBlock")
+		if lang := block["attrs"].(Node)["language"]; lang != "mermaid" {
+			t.Errorf("expected language 'mermaid', got %v", lang)
+		}
+	})
+
+	t.Run("panel", func(t *testing.T) {
+		panel := Convert(input, WithDiagramMode(DiagramPanel))["content"].([]Node)[0]
+		assertType(t, panel, "panel")
+		if panelType := panel["attrs"].(Node)["panelType"]; panelType != "info" {
+			t.Errorf("expected panelType 'info', got %v", panelType)
+		}
+		content := panel["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 panel nodes, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+		assertText(t, content[0]["content"].([]Node)[0], "Mermaid diagram omitted; source below.")
+		assertType(t, content[1], "codeBlock")
+		assertText(t, content[1]["content"].([]Node)[0], "graph TD\n  A --> B")
+	})
+
+	t.Run("panel leaves other code alone", func(t *testing.T) {
+		block := Convert("```go\nx := 1\n```", WithDiagramMode(DiagramPanel))["content"].([]Node)[0]
+		assertType(t, block, "codeBlock")
+	})
+
+	t.Run("link", func(t *testing.T) {
+		opts := []Option{WithDiagramMode(DiagramLink), WithDiagramRenderURL("https://kroki.example.com/")}
+		para := Convert("```PlantUML\nA -> B\n```", opts...)["content"].([]Node)[0]
+		assertType(t, para, "paragraph")
+		node := para["content"].([]Node)[0]
+		assertText(t, node, "View PlantUML diagram")
+		assertMarks(t, node, "link")
+
+		href := node["marks"].([]Node)[0]["attrs"].(Node)["href"].(string)
+		const prefix = "https://kroki.example.com/plantuml/svg/"
+		if !strings.HasPrefix(href, prefix) {
+			t.Fatalf("expected href to start with %q, got %q", prefix, href)
+		}
+		data, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(href, prefix))
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("inflate: %v", err)
+		}
+		source, _ := io.ReadAll(r)
+		if string(source) != "A -> B" {
+			t.Errorf("expected encoded source 'A -> B', got %q", source)
+		}
+	})
+
+	t.Run("link without render URL keeps code", func(t *testing.T) {
+		block := Convert(input, WithDiagramMode(DiagramLink))["content"].([]Node)[0]
+		assertType(t, block, "codeBlock")
+	})
+}
@@ -0,0 +1,214 @@
+package md2adf
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindColorTag is the goldmark node kind for {color:#rrggbb} and {/color}.
+var kindColorTag = ast.NewNodeKind("ColorTag")
+
+// colorTag is an opening {color:#rrggbb} or closing {/color} tag. The text
+// between a matching pair of sibling tags is rendered with a "textColor"
+// mark.
+type colorTag struct {
+	ast.BaseInline
+	// Color is the normalized #rrggbb color of an opening tag.
+	Color   string
+	Closing bool
+	// Segment covers the tag in the source, for rendering unmatched tags
+	// literally.
+	Segment text.Segment
+}
+
+// Kind implements [ast.Node].
+func (n *colorTag) Kind() ast.NodeKind { return kindColorTag }
+
+// Dump implements [ast.Node].
+func (n *colorTag) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Color": n.Color}, nil)
+}
+
+// colorTagPattern matches an opening tag with a 3 or 6 digit hex color, or a
+// closing tag.
+var colorTagPattern = regexp.MustCompile(`^\{(?:color:#([0-9a-fA-F]{6}|[0-9a-fA-F]{3})|(/color))\}`)
+
+// colorTagParser parses {color:#rrggbb} and {/color} tags. Tags with an
+// invalid color are not recognized and stay literal text.
+type colorTagParser struct{}
+
+func (p *colorTagParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+func (p *colorTagParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	m := colorTagPattern.FindSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	node := &colorTag{Segment: text.NewSegment(segment.Start, segment.Start+len(m[0]))}
+	if len(m[2]) > 0 {
+		node.Closing = true
+	} else {
+		node.Color, _ = normalizeHexColor("#" + string(m[1]))
+	}
+	block.Advance(len(m[0]))
+	return node
+}
+
+// hexColorPattern matches a 3 or 6 digit hex color with a leading '#'.
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{6}|[0-9a-fA-F]{3})$`)
+
+// normalizeHexColor converts a 3 or 6 digit hex color such as "#0A0" to the
+// lowercase 6 digit form "#00aa00" used by ADF color attrs. It reports false
+// for anything else.
+func normalizeHexColor(color string) (string, bool) {
+	if !hexColorPattern.MatchString(color) {
+		return "", false
+	}
+	hex := strings.ToLower(color[1:])
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	return "#" + hex, true
+}
+
+// alignAttr holds the alignment set by an {align:...} directive on a
+// paragraph.
+var alignAttr = []byte("md2adfAlign")
+
+// alignDirectivePattern matches an {align:...} directive and the spaces
+// following it.
+var alignDirectivePattern = regexp.MustCompile(`^\{align:(left|center|right|justify)\}[ \t]*`)
+
+// indentAttr holds the level set by an {indent:n} directive on a paragraph.
+var indentAttr = []byte("md2adfIndent")
+
+// indentDirectivePattern matches an {indent:n} directive and the spaces
+// following it.
+var indentDirectivePattern = regexp.MustCompile(`^\{indent:([0-9]+)\}[ \t]*`)
+
+// maxIndentLevel is the deepest paragraph indentation ADF allows.
+const maxIndentLevel = 6
+
+// blockDirectives lists the directives that [blockDirectiveTransformer]
+// recognizes and the paragraph attribute each one sets.
+var blockDirectives = []struct {
+	attr    []byte
+	pattern *regexp.Regexp
+}{
+	{alignAttr, alignDirectivePattern},
+	{indentAttr, indentDirectivePattern},
+}
+
+// blockDirectiveTransformer strips {align:...} and {indent:n} directives
+// from the start of a paragraph and records their values in the paragraph's
+// [alignAttr] and [indentAttr]. Directives with unknown values are left in
+// the text.
+type blockDirectiveTransformer struct{}
+
+func (t *blockDirectiveTransformer) Transform(node *ast.Paragraph, reader text.Reader, pc parser.Context) {
+	lines := node.Lines()
+	first := lines.At(0)
+	matched := false
+	for found := true; found; {
+		found = false
+		for _, directive := range blockDirectives {
+			if m := directive.pattern.FindSubmatch(first.Value(reader.Source())); m != nil {
+				node.SetAttribute(directive.attr, string(m[1]))
+				first = first.WithStart(first.Start + len(m[0]))
+				found, matched = true, true
+			}
+		}
+	}
+	if !matched {
+		return
+	}
+	if !util.IsBlank(first.Value(reader.Source())) {
+		lines.Set(0, first)
+		return
+	}
+	// The directives stand on a line of their own; goldmark cannot parse the
+	// inline content of empty lines, so drop the line.
+	if lines.Len() == 1 {
+		node.Parent().RemoveChild(node.Parent(), node)
+		return
+	}
+	rest := text.NewSegments()
+	rest.AppendAll(lines.Sliced(1, lines.Len()))
+	node.SetLines(rest)
+}
+
+// paragraphMarks returns the block marks for the alignment or indentation
+// recorded on n by an {align:...} or {indent:n} directive. ADF does not
+// allow both on one paragraph, so alignment wins.
+func paragraphMarks(n ast.Node) []Node {
+	if marks := alignmentMarks(n); len(marks) > 0 {
+		return marks
+	}
+	return indentationMarks(n)
+}
+
+// alignmentMarks returns the block marks for the alignment recorded on
+// n by an {align:...} directive. ADF only supports centered and end-aligned
+// paragraphs, so "left" and "justify" produce no mark and render with the
+// default alignment.
+func alignmentMarks(n ast.Node) []Node {
+	align, _ := n.AttributeString(string(alignAttr))
+	switch align {
+	case "center":
+		return []Node{{"type": "alignment", "attrs": Node{"align": "center"}}}
+	case "right":
+		return []Node{{"type": "alignment", "attrs": Node{"align": "end"}}}
+	}
+	return nil
+}
+
+// indentationMarks returns the block marks for the level recorded on n by
+// an {indent:n} directive, clamped to the levels ADF allows. Level 0
+// produces no mark.
+func indentationMarks(n ast.Node) []Node {
+	value, _ := n.AttributeString(string(indentAttr))
+	digits, _ := value.(string)
+	if digits == "" {
+		return nil
+	}
+	// Levels too large for an int are clamped like any other large level.
+	level, err := strconv.Atoi(digits)
+	if err != nil {
+		level = maxIndentLevel
+	}
+	if level = min(level, maxIndentLevel); level == 0 {
+		return nil
+	}
+	return []Node{{"type": "indentation", "attrs": Node{"level": level}}}
+}
+
+// directiveExtension registers the brace directive parsers with goldmark.
+type directiveExtension struct{}
+
+func (e directiveExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(&colorTagParser{}, 650)),
+		parser.WithParagraphTransformers(util.Prioritized(&blockDirectiveTransformer{}, 250)),
+	)
+}
+
+// hasClosingColorTag reports whether a closing {/color} tag follows n among
+// its siblings.
+func hasClosingColorTag(n ast.Node) bool {
+	for sibling := n.NextSibling(); sibling != nil; sibling = sibling.NextSibling() {
+		if tag, ok := sibling.(*colorTag); ok {
+			return tag.Closing
+		}
+	}
+	return false
+}
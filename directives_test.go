@@ -0,0 +1,207 @@
+package md2adf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvert_TextColor(t *testing.T) {
+	t.Run("valid color", func(t *testing.T) {
+		result := Convert("Status: {color:#FF0000}red text{/color} done")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Status: ")
+		assertMarks(t, paraContent[0])
+		assertText(t, paraContent[1], "red text")
+		assertMarks(t, paraContent[1], "textColor")
+		if color := paraContent[1]["marks"].([]Node)[0]["attrs"].(Node)["color"]; color != "#ff0000" {
+			t.Errorf("expected color '#ff0000', got %v", color)
+		}
+		assertText(t, paraContent[2], " done")
+		assertMarks(t, paraContent[2])
+	})
+
+	t.Run("short hex", func(t *testing.T) {
+		result := Convert("{color:#0a0}ok{/color}")
+		node := result["content"].([]Node)[0]["content"].([]Node)[0]
+		if color := node["marks"].([]Node)[0]["attrs"].(Node)["color"]; color != "#00aa00" {
+			t.Errorf("expected color '#00aa00', got %v", color)
+		}
+	})
+
+	t.Run("combined with bold and italic", func(t *testing.T) {
+		result := Convert("{color:#00875a}**Done** and *verified*{/color}")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertMarks(t, paraContent[0], "strong", "textColor")
+		assertMarks(t, paraContent[1], "textColor")
+		assertMarks(t, paraContent[2], "em", "textColor")
+	})
+
+	t.Run("invalid color stays literal", func(t *testing.T) {
+		result := Convert("{color:#zzz}text{/color}")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "{color:#zzz}text{/color}")
+		assertMarks(t, paraContent[0])
+	})
+
+	t.Run("unclosed stays literal", func(t *testing.T) {
+		result := Convert("{color:#ff0000}never closed")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "{color:#ff0000}never closed")
+		assertMarks(t, paraContent[0])
+	})
+}
+
+func TestConvert_ManyUnmatchedColorTags(t *testing.T) {
+	// Unmatched tags stay literal text that merges into a single node in
+	// linear time; 40000 tags used to take nine seconds.
+	input := strings.Repeat("{color:#fff}", 40000)
+	content := Convert(input)["content"].([]Node)[0]["content"].([]Node)
+	if len(content) != 1 {
+		t.Fatalf("expected 1 text node, got %d", len(content))
+	}
+	if content[0]["text"] != input {
+		t.Errorf("expected the tags as literal text")
+	}
+}
+
+func TestConvert_AlignDirective(t *testing.T) {
+	t.Run("center", func(t *testing.T) {
+		para := Convert("{align:center} Centered *text*\nsecond line")["content"].([]Node)[0]
+		assertType(t, para, "paragraph")
+		marks := para["marks"].([]Node)
+		if len(marks) != 1 || marks[0]["type"] != "alignment" {
+			t.Fatalf("expected an alignment mark, got %v", marks)
+		}
+		if align := marks[0]["attrs"].(Node)["align"]; align != "center" {
+			t.Errorf("expected align 'center', got %v", align)
+		}
+		content := para["content"].([]Node)
+		assertText(t, content[0], "Centered ")
+		assertText(t, content[1], "text")
+		assertMarks(t, content[1], "em")
+	})
+
+	t.Run("right maps to end", func(t *testing.T) {
+		para := Convert("{align:right}Total: 42")["content"].([]Node)[0]
+		if align := para["marks"].([]Node)[0]["attrs"].(Node)["align"]; align != "end" {
+			t.Errorf("expected align 'end', got %v", align)
+		}
+		assertText(t, para["content"].([]Node)[0], "Total: 42")
+	})
+
+	t.Run("own line", func(t *testing.T) {
+		content := Convert("{align:center}\nCentered\n\n{align:center}")["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		if align := content[0]["marks"].([]Node)[0]["attrs"].(Node)["align"]; align != "center" {
+			t.Errorf("expected align 'center', got %v", align)
+		}
+		assertText(t, content[0]["content"].([]Node)[0], "Centered")
+	})
+
+	t.Run("left and justify use the default", func(t *testing.T) {
+		for _, md := range []string{"{align:left} text", "{align:justify} text"} {
+			para := Convert(md)["content"].([]Node)[0]
+			if _, ok := para["marks"]; ok {
+				t.Errorf("%q: expected no marks, got %v", md, para["marks"])
+			}
+			assertText(t, para["content"].([]Node)[0], "text")
+		}
+	})
+
+	t.Run("invalid or misplaced stays literal", func(t *testing.T) {
+		for _, md := range []string{"{align:middle} text", "text {align:center}"} {
+			para := Convert(md)["content"].([]Node)[0]
+			if _, ok := para["marks"]; ok {
+				t.Errorf("%q: expected no marks, got %v", md, para["marks"])
+			}
+			assertText(t, para["content"].([]Node)[0], md)
+		}
+	})
+}
+
+func TestConvert_IndentDirective(t *testing.T) {
+	indentLevel := func(t *testing.T, para Node) any {
+		t.Helper()
+		marks, _ := para["marks"].([]Node)
+		if len(marks) != 1 || marks[0]["type"] != "indentation" {
+			t.Fatalf("expected an indentation mark, got %v", para["marks"])
+		}
+		return marks[0]["attrs"].(Node)["level"]
+	}
+
+	t.Run("indented paragraph", func(t *testing.T) {
+		para := Convert("{indent:2} Indented *text*")["content"].([]Node)[0]
+		assertType(t, para, "paragraph")
+		if level := indentLevel(t, para); level != 2 {
+			t.Errorf("expected level 2, got %v", level)
+		}
+		content := para["content"].([]Node)
+		assertText(t, content[0], "Indented ")
+		assertText(t, content[1], "text")
+		assertMarks(t, content[1], "em")
+	})
+
+	t.Run("own line", func(t *testing.T) {
+		content := Convert("{indent:1}\nIndented")["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		if level := indentLevel(t, content[0]); level != 1 {
+			t.Errorf("expected level 1, got %v", level)
+		}
+		assertText(t, content[0]["content"].([]Node)[0], "Indented")
+	})
+
+	t.Run("clamped", func(t *testing.T) {
+		for _, md := range []string{"{indent:7} text", "{indent:99999999999999999999} text"} {
+			if level := indentLevel(t, Convert(md)["content"].([]Node)[0]); level != 6 {
+				t.Errorf("%q: expected level 6, got %v", md, level)
+			}
+		}
+	})
+
+	t.Run("level zero", func(t *testing.T) {
+		para := Convert("{indent:0} text")["content"].([]Node)[0]
+		if _, ok := para["marks"]; ok {
+			t.Errorf("expected no marks, got %v", para["marks"])
+		}
+		assertText(t, para["content"].([]Node)[0], "text")
+	})
+
+	t.Run("alignment wins", func(t *testing.T) {
+		para := Convert("{indent:2}{align:center} text")["content"].([]Node)[0]
+		marks := para["marks"].([]Node)
+		if len(marks) != 1 || marks[0]["type"] != "alignment" {
+			t.Errorf("expected only an alignment mark, got %v", marks)
+		}
+		assertText(t, para["content"].([]Node)[0], "text")
+	})
+
+	t.Run("invalid stays literal", func(t *testing.T) {
+		for _, md := range []string{"{indent:-1} text", "{indent:two} text", "text {indent:2}"} {
+			para := Convert(md)["content"].([]Node)[0]
+			if _, ok := para["marks"]; ok {
+				t.Errorf("%q: expected no marks, got %v", md, para["marks"])
+			}
+			assertText(t, para["content"].([]Node)[0], md)
+		}
+	})
+}
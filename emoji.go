@@ -0,0 +1,168 @@
+package md2adf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// emojiShortNames maps Unicode emoji to the short names Jira and Confluence
+// know them by. Emoji whose default presentation is text, such as "❤", are
+// only listed followed by the U+FE0F variation selector that asks for the
+// emoji presentation, so that plain symbols stay text.
+var emojiShortNames = map[string]string{
+	"😀":       "grinning",
+	"😃":       "smiley",
+	"😄":       "smile",
+	"😁":       "grin",
+	"😆":       "laughing",
+	"😅":       "sweat_smile",
+	"😂":       "joy",
+	"🙂":       "slight_smile",
+	"🙃":       "upside_down",
+	"😉":       "wink",
+	"😊":       "blush",
+	"😍":       "heart_eyes",
+	"😎":       "sunglasses",
+	"🤔":       "thinking",
+	"😐":       "neutral_face",
+	"😬":       "grimacing",
+	"😢":       "cry",
+	"😭":       "sob",
+	"😡":       "rage",
+	"😱":       "scream",
+	"🥳":       "partying_face",
+	"🙈":       "see_no_evil",
+	"🤷":       "shrug",
+	"🤦":       "face_palm",
+	"👍":       "thumbsup",
+	"👎":       "thumbsdown",
+	"👌":       "ok_hand",
+	"👏":       "clap",
+	"🙌":       "raised_hands",
+	"🙏":       "pray",
+	"💪":       "muscle",
+	"👋":       "wave",
+	"👉":       "point_right",
+	"🤝":       "handshake",
+	"🤞":       "crossed_fingers",
+	"👀":       "eyes",
+	"❤\ufe0f": "heart",
+	"💔":       "broken_heart",
+	"💚":       "green_heart",
+	"💙":       "blue_heart",
+	"🔥":       "fire",
+	"✨":       "sparkles",
+	"⭐":       "star",
+	"🎉":       "tada",
+	"🚀":       "rocket",
+	"💡":       "bulb",
+	"⚡":       "zap",
+	"⚠\ufe0f": "warning",
+	"✅":       "white_check_mark",
+	"✔\ufe0f": "heavy_check_mark",
+	"❌":       "x",
+	"❓":       "question",
+	"❗":       "exclamation",
+	"⛔":       "no_entry",
+	"🛑":       "octagonal_sign",
+	"🚧":       "construction",
+	"🚨":       "rotating_light",
+	"🔴":       "red_circle",
+	"🟡":       "yellow_circle",
+	"🟢":       "green_circle",
+	"⏳":       "hourglass_flowing_sand",
+	"➡\ufe0f": "arrow_right",
+	"🐛":       "bug",
+	"🔒":       "lock",
+	"🔑":       "key",
+	"📝":       "memo",
+	"📌":       "pushpin",
+	"🔗":       "link",
+	"📈":       "chart_with_upwards_trend",
+	"📦":       "package",
+	"🔧":       "wrench",
+	"🔨":       "hammer",
+	"⚙\ufe0f": "gear",
+	"💻":       "computer",
+	"🏁":       "checkered_flag",
+	"🏆":       "trophy",
+	"🎁":       "gift",
+	"☕":       "coffee",
+	"🍻":       "beers",
+	"💯":       "100",
+}
+
+const (
+	// emojiPresentation is the variation selector requesting the emoji
+	// presentation of the preceding character.
+	emojiPresentation = '\ufe0f'
+	// zeroWidthJoiner combines emoji into sequences such as the one for
+	// "woman technologist".
+	zeroWidthJoiner = '\u200d'
+)
+
+// isSkinTone reports whether r is an emoji skin tone modifier.
+func isSkinTone(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// emojiNodes returns text as a "text" node carrying marks or, with
+// [WithUnicodeEmoji], splits it around the emoji listed in
+// [emojiShortNames], which become "emoji" nodes. Emoji that are part of a
+// sequence, such as one with a skin tone or joined to another emoji, stay
+// text, as do emoji in link text so that the link is not split.
+func (c *converter) emojiNodes(text string, marks []Node) []Node {
+	if !c.cfg.unicodeEmoji || hasMark(marks, "link") {
+		return []Node{newTextNode(text, marks)}
+	}
+	var nodes []Node
+	last := 0
+	var prev rune
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		end := i + size
+		next, nextSize := utf8.DecodeRuneInString(text[end:])
+		if next == emojiPresentation {
+			end += nextSize
+			next, _ = utf8.DecodeRuneInString(text[end:])
+		}
+		shortName, ok := emojiShortNames[text[i:end]]
+		if !ok {
+			shortName, ok = emojiShortNames[string(r)]
+		}
+		if ok && prev != zeroWidthJoiner && next != zeroWidthJoiner && !isSkinTone(next) {
+			if i > last {
+				nodes = append(nodes, newTextNode(text[last:i], marks))
+			}
+			nodes = append(nodes, emojiNode(shortName, text[i:end]))
+			last = end
+		}
+		prev, _ = utf8.DecodeLastRuneInString(text[:end])
+		i = end
+	}
+	if last < len(text) {
+		nodes = append(nodes, newTextNode(text[last:], marks))
+	}
+	return nodes
+}
+
+// emojiNode returns an ADF "emoji" node for the emoji text. Its id is the
+// hex code points of text without variation selectors, the id Atlassian
+// uses for standard emoji.
+func emojiNode(shortName, text string) Node {
+	var id []string
+	for _, r := range text {
+		if r != emojiPresentation {
+			id = append(id, fmt.Sprintf("%x", r))
+		}
+	}
+	return Node{
+		"type": "emoji",
+		"attrs": Node{
+			"shortName": ":" + shortName + ":",
+			"id":        strings.Join(id, "-"),
+			"text":      text,
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_UnicodeEmoji(t *testing.T) {
+	t.Run("emoji node", func(t *testing.T) {
+		inline := Convert("Looks good 👍 **ship it🚀**", WithUnicodeEmoji())["content"].([]Node)[0]["content"].([]Node)
+		if len(inline) != 5 {
+			t.Fatalf("expected 5 nodes, got %d: %v", len(inline), inline)
+		}
+		assertText(t, inline[0], "Looks good ")
+		assertType(t, inline[1], "emoji")
+		attrs := inline[1]["attrs"].(Node)
+		if attrs["shortName"] != ":thumbsup:" || attrs["id"] != "1f44d" || attrs["text"] != "👍" {
+			t.Errorf("unexpected emoji attrs %v", attrs)
+		}
+		assertText(t, inline[3], "ship it")
+		assertMarks(t, inline[3], "strong")
+		// Emoji nodes cannot carry the strong mark.
+		assertType(t, inline[4], "emoji")
+		if name := inline[4]["attrs"].(Node)["shortName"]; name != ":rocket:" {
+			t.Errorf("expected :rocket:, got %v", name)
+		}
+	})
+
+	t.Run("presentation selector", func(t *testing.T) {
+		inline := Convert("I ❤️ it, ❤ stays", WithUnicodeEmoji())["content"].([]Node)[0]["content"].([]Node)
+		if len(inline) != 3 {
+			t.Fatalf("expected 3 nodes, got %d: %v", len(inline), inline)
+		}
+		attrs := inline[1]["attrs"].(Node)
+		if attrs["shortName"] != ":heart:" || attrs["id"] != "2764" || attrs["text"] != "❤️" {
+			t.Errorf("unexpected emoji attrs %v", attrs)
+		}
+		assertText(t, inline[2], " it, ❤ stays")
+	})
+
+	t.Run("kept as text", func(t *testing.T) {
+		for _, input := range []string{
+			"skin tone 👍\U0001F3FD",
+			"joined 🤷‍♀️",
+			"[link 👍](https://example.com)",
+			"`code 👍`",
+			"unknown 🦄",
+		} {
+			for _, node := range collectNodes(Convert(input, WithUnicodeEmoji()), "emoji") {
+				t.Errorf("%q: unexpected emoji node %v", input, node)
+			}
+		}
+	})
+
+	t.Run("with issue keys", func(t *testing.T) {
+		inline := Convert("DEV-1 🎉", WithUnicodeEmoji(), WithIssueKeyLinking("https://jira.example.com/browse/"))["content"].([]Node)[0]["content"].([]Node)
+		assertType(t, inline[0], "inlineCard")
+		assertType(t, inline[2], "emoji")
+	})
+
+	t.Run("default keeps text", func(t *testing.T) {
+		inline := Convert("Looks good 👍")["content"].([]Node)[0]["content"].([]Node)
+		if len(inline) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(inline))
+		}
+		assertText(t, inline[0], "Looks good 👍")
+	})
+}
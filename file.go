@@ -0,0 +1,38 @@
+package md2adf
+
+import (
+	"fmt"
+	"os"
+)
+
+// ConvertFile reads the Markdown file at path and converts it like
+// [Convert]. It returns an error only if the file cannot be read.
+func ConvertFile(path string, opts ...Option) (Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(string(data), opts...), nil
+}
+
+// ConvertFileWithFrontmatter is like [ConvertFile] but first strips a
+// Jekyll or Hugo style frontmatter block from the top of the file and
+// returns its parsed keys separately. YAML frontmatter is delimited by
+// "---" lines and parsed as a subset of YAML (nested mappings, "- item"
+// sequences, and scalars); TOML frontmatter is delimited by "+++" lines
+// and parsed as "key = value" lines and "[table]" headers. Values beyond
+// these subsets, such as YAML anchors or multi-line strings, are returned
+// as their raw text, and [ConvertWithDiagnostics] with [FrontmatterStrip]
+// reports them; only malformed structure is an error. The frontmatter map
+// is nil if the file has none.
+func ConvertFileWithFrontmatter(path string, opts ...Option) (Node, map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	meta, body, _, err := parseFrontmatter(string(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("md2adf: %s: frontmatter %w", path, err)
+	}
+	return Convert(body, opts...), meta, nil
+}
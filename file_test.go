@@ -0,0 +1,178 @@
+package md2adf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to a file in a temporary directory and returns
+// its path.
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConvertFile(t *testing.T) {
+	doc, err := ConvertFile(writeFile(t, "# Title\n\nBody."))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := doc["content"].([]Node)
+	if len(content) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(content))
+	}
+	assertType(t, content[0], "heading")
+
+	if _, err := ConvertFile(filepath.Join(t.TempDir(), "missing.md")); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
+
+func TestConvertFileWithFrontmatter(t *testing.T) {
+	t.Run("with frontmatter", func(t *testing.T) {
+		path := writeFile(t, "---\n"+
+			"title: \"Release: 1.0\"\n"+
+			"draft: false\n"+
+			"weight: 10\n"+
+			"ratio: 0.5\n"+
+			"date: 2024-01-15 # publication date\n"+
+			"tags: [go, 'adf']\n"+
+			"authors:\n"+
+			"  - alice\n"+
+			"  - bob\n"+
+			"params:\n"+
+			"  toc: true\n"+
+			"  summary:\n"+
+			"---\n"+
+			"# Title\n")
+		doc, meta, err := ConvertFileWithFrontmatter(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]any{
+			"title":   "Release: 1.0",
+			"draft":   false,
+			"weight":  10,
+			"ratio":   0.5,
+			"date":    "2024-01-15",
+			"tags":    []any{"go", "adf"},
+			"authors": []any{"alice", "bob"},
+			"params":  map[string]any{"toc": true, "summary": nil},
+		}
+		if !reflect.DeepEqual(meta, want) {
+			t.Errorf("expected frontmatter %v, got %v", want, meta)
+		}
+		content := doc["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected only the heading, got %d nodes", len(content))
+		}
+		assertType(t, content[0], "heading")
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		path := writeFile(t, "+++\ntitle = 'Hello'\ndraft = true\ntags = [\"go\", \"adf\"]\n\n[params]\ntoc = false\n+++\nBody\n")
+		doc, meta, err := ConvertFileWithFrontmatter(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]any{
+			"title":  "Hello",
+			"draft":  true,
+			"tags":   []any{"go", "adf"},
+			"params": map[string]any{"toc": false},
+		}
+		if !reflect.DeepEqual(meta, want) {
+			t.Errorf("expected frontmatter %v, got %v", want, meta)
+		}
+		if content := doc["content"].([]Node); len(content) != 1 {
+			t.Errorf("expected only the body paragraph, got %d nodes", len(content))
+		}
+	})
+
+	t.Run("without frontmatter", func(t *testing.T) {
+		doc, meta, err := ConvertFileWithFrontmatter(writeFile(t, "Text\n\n---\n\nMore"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if meta != nil {
+			t.Errorf("expected nil frontmatter, got %v", meta)
+		}
+		if content := doc["content"].([]Node); len(content) != 3 {
+			t.Errorf("expected 3 nodes, got %d", len(content))
+		}
+	})
+
+	t.Run("unclosed frontmatter is content", func(t *testing.T) {
+		doc, meta, err := ConvertFileWithFrontmatter(writeFile(t, "---\ntitle: x\n"))
+		if err != nil || meta != nil {
+			t.Fatalf("expected no frontmatter and no error, got %v, %v", meta, err)
+		}
+		assertType(t, doc["content"].([]Node)[0], "rule")
+	})
+
+	t.Run("quoted commas and trailing comments", func(t *testing.T) {
+		path := writeFile(t, "---\n"+
+			"tags: [\"a, b\", c, 'it''s, ok', [d, \"e]\"],]\n"+
+			"title: \"a\" # say \"hi\"\n"+
+			"note: 'x' # it's\n"+
+			"escaped: \"say \\\"hi\\\", then # go\" # done\n"+
+			"---\n"+
+			"Body\n")
+		_, meta, err := ConvertFileWithFrontmatter(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]any{
+			"tags":    []any{"a, b", "c", "it's, ok", []any{"d", "e]"}},
+			"title":   "a",
+			"note":    "x",
+			"escaped": `say "hi", then # go`,
+		}
+		if !reflect.DeepEqual(meta, want) {
+			t.Errorf("expected frontmatter %v, got %v", want, meta)
+		}
+
+		_, meta, err = ConvertFileWithFrontmatter(writeFile(t, "+++\ntags = [\"a, b\", \"c\"]\ntitle = \"a\" # say \"hi\"\n+++\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := map[string]any{"tags": []any{"a, b", "c"}, "title": "a"}; !reflect.DeepEqual(meta, want) {
+			t.Errorf("expected frontmatter %v, got %v", want, meta)
+		}
+	})
+
+	t.Run("unsupported values kept as text", func(t *testing.T) {
+		src := "---\ntitle: x\nanchor: &ref value\nbroken: \"unclosed\n---\nBody <div>x</div>\n"
+		doc, meta, err := ConvertFileWithFrontmatter(writeFile(t, src))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]any{"title": "x", "anchor": "&ref value", "broken": `"unclosed`}
+		if !reflect.DeepEqual(meta, want) {
+			t.Errorf("expected frontmatter %v, got %v", want, meta)
+		}
+		if content := doc["content"].([]Node); len(content) != 1 {
+			t.Errorf("expected only the body paragraph, got %d nodes", len(content))
+		}
+
+		_, diags := ConvertWithDiagnostics(src, WithFrontmatter(FrontmatterStrip))
+		// The frontmatter values, then the raw HTML tags of the body.
+		if len(diags) != 4 || diags[0].Line != 3 || diags[1].Line != 4 || diags[2].Line != 6 {
+			t.Errorf("expected diagnostics on lines 3, 4, and 6, got %v", diags)
+		}
+	})
+
+	t.Run("invalid frontmatter", func(t *testing.T) {
+		_, _, err := ConvertFileWithFrontmatter(writeFile(t, "---\ntitle: x\nnot yaml\n---\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("expected an error for line 3, got %v", err)
+		}
+	})
+}
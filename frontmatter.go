@@ -0,0 +1,344 @@
+package md2adf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitFrontmatter splits a leading frontmatter block from the Markdown
+// body of src. YAML frontmatter is delimited by a "---" line at the very
+// start of src and a closing "---" or "..." line, TOML frontmatter by "+++"
+// lines. It returns the opening fence, "---" or "+++", and the lines
+// between the fences, which start on source line 2. fence is empty, and
+// body is src, if src has no complete frontmatter block.
+func splitFrontmatter(src string) (fence, front, body string) {
+	rest := strings.TrimPrefix(src, "\ufeff")
+	open, rest, found := strings.Cut(rest, "\n")
+	fence = strings.TrimRight(open, " \t\r")
+	if !found || (fence != "---" && fence != "+++") {
+		return "", "", src
+	}
+	for offset := 0; offset <= len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		if end < 0 {
+			end = len(rest) - offset
+		}
+		if line := strings.TrimRight(rest[offset:offset+end], " \t\r"); line == fence || (fence == "---" && line == "...") {
+			if offset+end < len(rest) {
+				body = rest[offset+end+1:]
+			}
+			return fence, rest[:offset], body
+		}
+		offset += end + 1
+	}
+	return "", "", src
+}
+
+// parseFrontmatter parses the frontmatter block at the top of src, if any,
+// and returns its keys and the Markdown body after it. meta is nil if src
+// has no frontmatter.
+func parseFrontmatter(src string) (meta map[string]any, body string, diags []Diagnostic, err error) {
+	fence, front, body := splitFrontmatter(src)
+	switch fence {
+	case "---":
+		meta, diags, err = parseFrontmatterYAML(front, 2)
+	case "+++":
+		meta, diags, err = parseFrontmatterTOML(front, 2)
+	}
+	return meta, body, diags, err
+}
+
+// parseFrontmatterTOML parses TOML frontmatter src into a map, supporting
+// "key = value" lines and single-level "[table]" headers. Values follow the
+// same rules as [yamlScalar], which covers TOML strings, numbers, booleans,
+// and arrays; values it cannot parse are kept as their raw text and
+// reported in diags. Line numbers are counted from firstLine.
+func parseFrontmatterTOML(src string, firstLine int) (m map[string]any, diags []Diagnostic, err error) {
+	m = map[string]any{}
+	table := m
+	for i, raw := range strings.Split(src, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && !strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]") {
+			table = map[string]any{}
+			m[strings.TrimSpace(line[1:len(line)-1])] = table
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, nil, fmt.Errorf("line %d: expected \"key = value\"", firstLine+i)
+		}
+		v, err := yamlScalar(value)
+		if err != nil {
+			v = value
+			diags = append(diags, rawValueDiagnostic(firstLine+i, err))
+		}
+		table[key] = v
+	}
+	return m, diags, nil
+}
+
+// rawValueDiagnostic reports a frontmatter value on line that could not be
+// parsed and is kept as its raw text.
+func rawValueDiagnostic(line int, err error) Diagnostic {
+	return Diagnostic{Message: fmt.Sprintf("frontmatter value kept as text: %v", err), Line: line, Column: 1}
+}
+
+// yamlLine is a non-blank, non-comment line of YAML frontmatter.
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+// yamlParser parses the small subset of YAML used in frontmatter: nested
+// mappings of "key: value" lines, block sequences of "- item" lines, and
+// scalar values, including quoted strings and "[a, b]" flow sequences.
+// Anchors, multi-line strings, and flow mappings are not supported.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+	// diags reports values that could not be parsed and are kept as their
+	// raw text.
+	diags []Diagnostic
+}
+
+// parseFrontmatterYAML parses frontmatter src into a map. Line numbers are
+// counted from firstLine, the source line src starts on. Integers become
+// int, other numbers float64, true and false bool, null and "~" nil, and
+// everything else a string. Scalar values outside the supported subset are
+// kept as their raw text and reported in diags; malformed structure is an
+// error.
+func parseFrontmatterYAML(src string, firstLine int) (m map[string]any, diags []Diagnostic, err error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(src, "\n") {
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+		if strings.Contains(raw[:indent], "\t") {
+			return nil, nil, fmt.Errorf("line %d: tabs are not allowed in indentation", firstLine+i)
+		}
+		p.lines = append(p.lines, yamlLine{indent: indent, text: text, num: firstLine + i})
+	}
+	if len(p.lines) == 0 {
+		return map[string]any{}, nil, nil
+	}
+	if m, err = p.mapping(p.lines[0].indent); err != nil {
+		return nil, nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return m, p.diags, nil
+}
+
+// scalar parses the value on line with [yamlScalar], falling back to the
+// raw text of values it cannot parse.
+func (p *yamlParser) scalar(value string, line yamlLine) any {
+	v, err := yamlScalar(value)
+	if err != nil {
+		p.diags = append(p.diags, rawValueDiagnostic(line.num, err))
+		return value
+	}
+	return v
+}
+
+// mapping parses the "key: value" lines at indent.
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		key, value, ok := strings.Cut(line.text, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.HasPrefix(line.text, "-") || (value != "" && value[0] != ' ') {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		p.pos++
+		if value = strings.TrimSpace(value); value != "" && !strings.HasPrefix(value, "#") {
+			m[key] = p.scalar(value, line)
+			continue
+		}
+
+		// An empty value opens a nested block; sequences may also sit at the
+		// indentation of their key.
+		var err error
+		switch next := p.next(); {
+		case next != nil && isYAMLItem(next.text) && next.indent >= indent:
+			m[key], err = p.sequence(next.indent)
+		case next != nil && next.indent > indent:
+			m[key], err = p.mapping(next.indent)
+		default:
+			m[key] = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// sequence parses the "- item" lines at indent. Items must be scalars.
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		p.pos++
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if item == "" {
+			return nil, fmt.Errorf("line %d: nested sequence items are not supported", line.num)
+		}
+		items = append(items, p.scalar(item, line))
+	}
+	return items, nil
+}
+
+// next returns the line to be parsed next, or nil at the end.
+func (p *yamlParser) next() *yamlLine {
+	if p.pos >= len(p.lines) {
+		return nil
+	}
+	return &p.lines[p.pos]
+}
+
+// isYAMLItem reports whether text is a block sequence item.
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// yamlScalar parses a single YAML value.
+func yamlScalar(s string) (any, error) {
+	switch s[0] {
+	case '"':
+		end := quotedEnd(s)
+		if end < 0 || !isYAMLComment(s[end+1:]) {
+			return nil, fmt.Errorf("invalid double-quoted string %s", s)
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid double-quoted string %s", s)
+		}
+		return v, nil
+	case '\'':
+		end := quotedEnd(s)
+		if end < 0 || !isYAMLComment(s[end+1:]) {
+			return nil, fmt.Errorf("invalid single-quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:end], "''", "'"), nil
+	case '[':
+		parts, end := splitFlowSequence(s)
+		if end < 0 || !isYAMLComment(s[end+1:]) {
+			return nil, fmt.Errorf("invalid flow sequence %s", s)
+		}
+		items := []any{}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				// "[]" has no items, and a trailing comma ends the last one.
+				if len(parts) == 1 || (i == len(parts)-1 && i > 0) {
+					continue
+				}
+				return nil, fmt.Errorf("empty item in flow sequence %s", s)
+			}
+			v, err := yamlScalar(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case '{', '&', '*', '|', '>':
+		return nil, fmt.Errorf("unsupported YAML value %s", s)
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i, nil
+	}
+	if strings.ContainsAny(s[:1], "0123456789+-.") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+	return s, nil
+}
+
+// isYAMLComment reports whether s, the text after a quoted value, is empty
+// or a comment.
+func isYAMLComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
+
+// quotedEnd returns the index of the quote closing the quoted scalar that
+// s starts with, or -1 if it is not closed. Double-quoted scalars escape
+// quotes with a backslash, single-quoted ones by doubling them.
+func quotedEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitFlowSequence splits the flow sequence s starts with into its items
+// at the commas outside quoted scalars and nested sequences. It returns the
+// index of the closing bracket, or -1 if the sequence is not closed.
+func splitFlowSequence(s string) (items []string, end int) {
+	depth, start := 0, 1
+	// itemStart is set until the first character of an item, the only
+	// place a quote opens a quoted scalar.
+	itemStart := true
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case (c == '"' || c == '\'') && itemStart:
+			n := quotedEnd(s[i:])
+			if n < 0 {
+				return nil, -1
+			}
+			i += n
+			itemStart = false
+		case c == '[':
+			depth++
+			itemStart = true
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return append(items, s[start:i]), i
+			}
+			itemStart = false
+		case c == ',' && depth == 1:
+			items = append(items, s[start:i])
+			start = i + 1
+			itemStart = true
+		case c == ',':
+			itemStart = true
+		case c != ' ' && c != '\t':
+			itemStart = false
+		}
+	}
+	return nil, -1
+}
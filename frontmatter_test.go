@@ -0,0 +1,50 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_Frontmatter(t *testing.T) {
+	yaml := "---\ntitle: Hello\ntags: [a, b]\n---\n# Heading\n\nBody <div>x</div>\n"
+	toml := "+++\ntitle = \"Hello\"\n+++\n# Heading\n\nBody <div>x</div>\n"
+
+	tests := []struct {
+		name     string
+		markdown string
+		bodyLine int
+	}{
+		{"yaml", yaml, 7},
+		{"toml", toml, 6},
+	}
+	for _, tt := range tests {
+		md := tt.markdown
+		t.Run(tt.name, func(t *testing.T) {
+			// Kept by default.
+			content := Convert(md)["content"].([]Node)
+			if len(content) < 3 {
+				t.Fatalf("expected frontmatter converted as content, got %d nodes", len(content))
+			}
+
+			doc, diags := ConvertWithDiagnostics(md, WithFrontmatter(FrontmatterStrip))
+			content = doc["content"].([]Node)
+			if len(content) != 2 {
+				t.Fatalf("expected 2 nodes, got %d", len(content))
+			}
+			assertType(t, content[0], "heading")
+			assertText(t, content[0]["content"].([]Node)[0], "Heading")
+			assertType(t, content[1], "paragraph")
+
+			// Diagnostics refer to lines of the original source.
+			if len(diags) != 2 || diags[0].Line != tt.bodyLine {
+				t.Errorf("expected diagnostics on line %d, got %v", tt.bodyLine, diags)
+			}
+		})
+	}
+
+	t.Run("not frontmatter", func(t *testing.T) {
+		for _, md := range []string{"Text\n---\n", "---\ntitle: unclosed\n", " ---\nx: y\n---\n"} {
+			stripped := Convert(md, WithFrontmatter(FrontmatterStrip))
+			if got, want := stableJSON(t, stripped), stableJSON(t, Convert(md)); got != want {
+				t.Errorf("%q: expected unchanged output\ngot:  %s\nwant: %s", md, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package md2adf
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// defaultHighlightColor is the backgroundColor of ==highlighted== text,
+// matching the yellow of the Jira and Confluence editors.
+const defaultHighlightColor = "#fff0b3"
+
+// kindHighlight is the goldmark node kind for ==highlighted== spans.
+var kindHighlight = ast.NewNodeKind("Highlight")
+
+// highlight is an inline ==highlighted== span. Its children hold the
+// highlighted content.
+type highlight struct {
+	ast.BaseInline
+}
+
+// Kind implements [ast.Node].
+func (n *highlight) Kind() ast.NodeKind { return kindHighlight }
+
+// Dump implements [ast.Node].
+func (n *highlight) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// highlightDelimiterProcessor pairs == delimiters, following the same
+// flanking rules as strikethrough.
+type highlightDelimiterProcessor struct{}
+
+func (p *highlightDelimiterProcessor) IsDelimiter(b byte) bool {
+	return b == '='
+}
+
+func (p *highlightDelimiterProcessor) CanOpenCloser(opener, closer *parser.Delimiter) bool {
+	return opener.Char == closer.Char
+}
+
+func (p *highlightDelimiterProcessor) OnMatch(consumes int) ast.Node {
+	return &highlight{}
+}
+
+// highlightParser parses runs of exactly two '=' as highlight delimiters.
+// Longer runs such as "===" and single '=' signs stay literal text, and
+// code spans are parsed first, so "a == b" in code is unaffected.
+type highlightParser struct{}
+
+func (p *highlightParser) Trigger() []byte {
+	return []byte{'='}
+}
+
+func (p *highlightParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	before := block.PrecendingCharacter()
+	line, segment := block.PeekLine()
+	node := parser.ScanDelimiter(line, before, 2, &highlightDelimiterProcessor{})
+	if node == nil || node.OriginalLength != 2 || before == '=' {
+		return nil
+	}
+	node.Segment = segment.WithStop(segment.Start + node.OriginalLength)
+	block.Advance(node.OriginalLength)
+	pc.PushDelimiter(node)
+	return node
+}
+
+// highlightExtension registers the ==highlight== parser with goldmark.
+type highlightExtension struct{}
+
+func (e highlightExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(&highlightParser{}, 500)),
+	)
+}
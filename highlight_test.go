@@ -0,0 +1,57 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_Highlight(t *testing.T) {
+	t.Run("mid-sentence", func(t *testing.T) {
+		result := Convert("This is ==really important== text.")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "This is ")
+		assertMarks(t, paraContent[0])
+		assertText(t, paraContent[1], "really important")
+		assertMarks(t, paraContent[1], "backgroundColor")
+		if color := paraContent[1]["marks"].([]Node)[0]["attrs"].(Node)["color"]; color != "#fff0b3" {
+			t.Errorf("expected color '#fff0b3', got %v", color)
+		}
+		assertText(t, paraContent[2], " text.")
+	})
+
+	t.Run("inside a list with bold", func(t *testing.T) {
+		result := Convert("- plain\n- ==**key** point==")
+		items := result["content"].([]Node)[0]["content"].([]Node)
+		paraContent := items[1]["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "key")
+		assertMarks(t, paraContent[0], "strong", "backgroundColor")
+		assertText(t, paraContent[1], " point")
+		assertMarks(t, paraContent[1], "backgroundColor")
+	})
+
+	t.Run("custom color", func(t *testing.T) {
+		result := Convert("==done==", WithHighlightColor("#E3FCEF"))
+		node := result["content"].([]Node)[0]["content"].([]Node)[0]
+		if color := node["marks"].([]Node)[0]["attrs"].(Node)["color"]; color != "#e3fcef" {
+			t.Errorf("expected color '#e3fcef', got %v", color)
+		}
+	})
+
+	t.Run("equality signs stay literal", func(t *testing.T) {
+		for _, input := range []string{"a == b", "x === y", "`a==b==c`", "a = b", "==unclosed", `\==escaped==`} {
+			for _, node := range collectNodes(Convert(input), "text") {
+				if marks, _ := node["marks"].([]Node); hasMark(marks, "backgroundColor") {
+					t.Errorf("%q: unexpected highlight on %v", input, node)
+				}
+			}
+		}
+		code := Convert("`a==b==c`")["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, code, "a==b==c")
+		assertMarks(t, code, "code")
+	})
+}
@@ -0,0 +1,471 @@
+package md2adf
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// rawHTMLText returns the source text of an inline [ast.RawHTML] node, which
+// goldmark stores as a list of segments.
+func (c *converter) rawHTMLText(node *ast.RawHTML) string {
+	var sb strings.Builder
+	for i := 0; i < node.Segments.Len(); i++ {
+		segment := node.Segments.At(i)
+		sb.Write(segment.Value(c.source))
+	}
+	return sb.String()
+}
+
+// parseHTMLTag extracts the lower-cased tag name from a single HTML tag such
+// as "<kbd>", "</kbd>", or "<br/>" and reports whether it is a closing tag.
+// Attributes are ignored. ok is false if raw is not a single tag.
+func parseHTMLTag(raw string) (name string, closing, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) < 3 || raw[0] != '<' || raw[len(raw)-1] != '>' {
+		return "", false, false
+	}
+	inner := raw[1 : len(raw)-1]
+	if strings.HasPrefix(inner, "/") {
+		closing = true
+		inner = inner[1:]
+	}
+	inner = strings.TrimSuffix(inner, "/")
+	end := strings.IndexAny(inner, " \t\n/")
+	if end >= 0 {
+		inner = inner[:end]
+	}
+	if inner == "" || strings.ContainsAny(inner, "<>!?") {
+		return "", false, false
+	}
+	return strings.ToLower(inner), closing, true
+}
+
+// hasClosingHTMLTag reports whether a closing tag for name follows n among
+// its siblings, before any other opening tag of the same name.
+func (c *converter) hasClosingHTMLTag(n ast.Node, name string) bool {
+	for sibling := n.NextSibling(); sibling != nil; sibling = sibling.NextSibling() {
+		raw, ok := sibling.(*ast.RawHTML)
+		if !ok {
+			continue
+		}
+		if tag, closing, ok := parseHTMLTag(c.rawHTMLText(raw)); ok && tag == name {
+			return closing
+		}
+	}
+	return false
+}
+
+// htmlComment returns the trimmed text of raw if raw consists of a single
+// HTML comment such as "<!-- note -->".
+func htmlComment(raw string) (text string, ok bool) {
+	inner, ok := strings.CutPrefix(strings.TrimSpace(raw), "<!--")
+	if !ok {
+		return "", false
+	}
+	inner, ok = strings.CutSuffix(inner, "-->")
+	if !ok || strings.Contains(inner, "-->") {
+		return "", false
+	}
+	return strings.TrimSpace(inner), true
+}
+
+// Comment directives recognized with [WithCommentDirectives].
+const (
+	ignoreStartDirective = "adf:ignore-start"
+	ignoreEndDirective   = "adf:ignore-end"
+)
+
+// ignoreDirective reports whether raw HTML is an ignore-start or ignore-end
+// comment directive. Both are false unless [WithCommentDirectives] is set.
+func (c *converter) ignoreDirective(raw string) (start, end bool) {
+	if !c.cfg.commentDirectives {
+		return false, false
+	}
+	text, _ := htmlComment(raw)
+	return text == ignoreStartDirective, text == ignoreEndDirective
+}
+
+// htmlBlockText returns the full source text of a block-level
+// [ast.HTMLBlock], including its closing line if it has one.
+func (c *converter) htmlBlockText(node *ast.HTMLBlock) string {
+	var sb strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		sb.Write(segment.Value(c.source))
+	}
+	if node.HasClosure() {
+		sb.Write(node.ClosureLine.Value(c.source))
+	}
+	return sb.String()
+}
+
+// htmlTableCell is a single <td> or <th> cell of an HTML table.
+type htmlTableCell struct {
+	header  bool
+	colspan int
+	rowspan int
+	// width is the pixel width given by the cell's width attribute or
+	// style, or 0.
+	width float64
+	// colwidth holds the pixel widths of the columns the cell spans, or nil
+	// if the table does not specify all of them.
+	colwidth []float64
+	// inner is the raw HTML between the cell's opening and closing tags.
+	inner string
+}
+
+// htmlTagPattern matches an opening or closing HTML tag and captures the
+// closing slash, the tag name, and the raw attribute text.
+var htmlTagPattern = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:[^>"']|"[^"]*"|'[^']*')*)>`)
+
+// htmlAttrPattern matches a single attribute with an optional quoted or
+// unquoted value.
+var htmlAttrPattern = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+)))?`)
+
+// parseHTMLTable parses raw, which must start with a <table> tag, into rows
+// of cells. It is a deliberately small tokenizer rather than a full HTML
+// parser: it understands <tr>, <td>, and <th> (including implicitly closed
+// cells and rows) and ignores <thead>, <tbody>, and similar wrappers. Column
+// widths are taken from <col> tags and the width attribute or style of
+// cells, as in tables exported from Confluence. Tables nested inside a cell
+// are kept verbatim in that cell's inner HTML. ok is false if raw does not
+// hold a table with at least one cell.
+func parseHTMLTable(raw string) (rows [][]htmlTableCell, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if name, closing, isTag := parseHTMLTag(firstHTMLTag(raw)); !isTag || closing || name != "table" {
+		return nil, false
+	}
+
+	var row []htmlTableCell
+	var cell *htmlTableCell
+	cellStart := 0
+	depth := 0
+	// cols holds the widths given by <col> tags.
+	var cols []float64
+
+	closeCell := func(end int) {
+		if cell != nil {
+			cell.inner = raw[cellStart:end]
+			row = append(row, *cell)
+			cell = nil
+		}
+	}
+	closeRow := func(end int) {
+		closeCell(end)
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+		row = nil
+	}
+
+	for _, m := range htmlTagPattern.FindAllStringSubmatchIndex(raw, -1) {
+		closing := m[3] > m[2]
+		name := strings.ToLower(raw[m[4]:m[5]])
+		if name == "table" {
+			if closing {
+				depth--
+			} else {
+				depth++
+			}
+			if depth == 0 {
+				closeRow(m[0])
+				break
+			}
+			continue
+		}
+		if depth != 1 {
+			continue
+		}
+		switch name {
+		case "tr":
+			closeRow(m[0])
+		case "col":
+			if !closing {
+				attrs := parseHTMLAttrs(raw[m[6]:m[7]])
+				for range htmlSpan(attrs["span"]) {
+					cols = append(cols, htmlWidth(attrs))
+				}
+			}
+		case "td", "th":
+			closeCell(m[0])
+			if !closing {
+				attrs := parseHTMLAttrs(raw[m[6]:m[7]])
+				cell = &htmlTableCell{
+					header:  name == "th",
+					colspan: htmlSpan(attrs["colspan"]),
+					rowspan: htmlSpan(attrs["rowspan"]),
+					width:   htmlWidth(attrs),
+				}
+				cellStart = m[1]
+			}
+		}
+	}
+	closeRow(len(raw))
+	resolveColumnWidths(rows, cols)
+	return rows, len(rows) > 0
+}
+
+// firstHTMLTag returns the first tag in raw, or "" if raw does not start with
+// a tag.
+func firstHTMLTag(raw string) string {
+	loc := htmlTagPattern.FindStringIndex(raw)
+	if loc == nil || loc[0] != 0 {
+		return ""
+	}
+	return raw[loc[0]:loc[1]]
+}
+
+// parseHTMLAttrs parses the attribute portion of an HTML tag into a map keyed
+// by lower-cased attribute name.
+func parseHTMLAttrs(raw string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range htmlAttrPattern.FindAllStringSubmatch(raw, -1) {
+		attrs[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+	}
+	return attrs
+}
+
+// maxHTMLSpan is the largest span honoured, the limit browsers apply to
+// colspan.
+const maxHTMLSpan = 1000
+
+// htmlSpan parses a colspan, rowspan, or span attribute value, returning 1
+// for missing or invalid values and clamping it to [maxHTMLSpan].
+func htmlSpan(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, maxHTMLSpan)
+}
+
+// resolveColumnWidths sets the colwidth of every cell to the widths of the
+// columns it spans. A column's width is given by its <col> tag or else by
+// the first cell of its own in the column with a width; cells are only
+// annotated if all their columns have a width.
+func resolveColumnWidths(rows [][]htmlTableCell, cols []float64) {
+	// Find the first column of every cell, skipping columns still spanned
+	// by a cell from a row above; covered counts those rows per column.
+	starts := make([][]int, len(rows))
+	var covered []int
+	for r, row := range rows {
+		col := 0
+		for _, cell := range row {
+			for col < len(covered) && covered[col] > 0 {
+				col++
+			}
+			for len(covered) < col+cell.colspan {
+				covered = append(covered, 0)
+			}
+			for j := range cell.colspan {
+				covered[col+j] = cell.rowspan
+			}
+			starts[r] = append(starts[r], col)
+			col += cell.colspan
+		}
+		for j := range covered {
+			if covered[j] > 0 {
+				covered[j]--
+			}
+		}
+	}
+
+	widths := make([]float64, len(covered))
+	copy(widths, cols)
+	for r, row := range rows {
+		for i, cell := range row {
+			if col := starts[r][i]; cell.colspan == 1 && cell.width > 0 && widths[col] == 0 {
+				widths[col] = cell.width
+			}
+		}
+	}
+	for r, row := range rows {
+		for i := range row {
+			cell := &row[i]
+			spanned := widths[starts[r][i] : starts[r][i]+cell.colspan]
+			if !slices.Contains(spanned, 0) {
+				cell.colwidth = slices.Clone(spanned)
+			}
+		}
+	}
+}
+
+// cssWidthPattern matches a pixel width declaration in a style attribute.
+var cssWidthPattern = regexp.MustCompile(`(?i)(?:^|;)\s*width\s*:\s*([0-9]+(?:\.[0-9]+)?)px`)
+
+// htmlWidth returns the pixel width given by the style or width attribute of
+// a cell or column, or 0 if there is none. Relative widths such as
+// percentages are ignored.
+func htmlWidth(attrs map[string]string) float64 {
+	value := strings.TrimSuffix(strings.TrimSpace(attrs["width"]), "px")
+	if m := cssWidthPattern.FindStringSubmatch(attrs["style"]); m != nil {
+		value = m[1]
+	}
+	width, err := strconv.ParseFloat(value, 64)
+	if err != nil || width <= 0 || math.IsInf(width, 0) {
+		return 0
+	}
+	return width
+}
+
+// convertHTMLTable converts the rows of a parsed HTML table into an ADF
+// "table" node with the same attrs as a GFM table. <th> cells become
+// "tableHeader" and <td> cells "tableCell"; colspan and rowspan greater than
+// one are carried over as cell attrs, as are known column widths as the
+// "colwidth" attr. htmlBlock is the HTML block holding the table.
+func (c *converter) convertHTMLTable(htmlBlock ast.Node, rows [][]htmlTableCell) Node {
+	var adfRows []Node
+	for _, row := range rows {
+		var cells []Node
+		for _, cell := range row {
+			cellType := "tableCell"
+			if cell.header {
+				cellType = "tableHeader"
+			}
+			adfCell := Node{
+				"type":    cellType,
+				"content": c.convertHTMLCellContent(htmlBlock, cell.inner),
+			}
+			if cell.colspan > 1 || cell.rowspan > 1 || cell.colwidth != nil {
+				attrs := Node{}
+				if cell.colspan > 1 {
+					attrs["colspan"] = cell.colspan
+				}
+				if cell.rowspan > 1 {
+					attrs["rowspan"] = cell.rowspan
+				}
+				if cell.colwidth != nil {
+					attrs["colwidth"] = cell.colwidth
+				}
+				adfCell["attrs"] = attrs
+			}
+			cells = append(cells, adfCell)
+		}
+		adfRows = append(adfRows, Node{
+			"type":    "tableRow",
+			"content": cells,
+		})
+	}
+	return Node{
+		"type":    "table",
+		"attrs":   c.tableAttrs(),
+		"content": adfRows,
+	}
+}
+
+// nestedTablePattern matches the opening tag of a table nested in a cell.
+var nestedTablePattern = regexp.MustCompile(`(?i)<table[\s>]`)
+
+// convertHTMLCellContent converts the inner HTML of a table cell by treating
+// it as Markdown, so that Markdown formatting inside cells is honoured and
+// any remaining inline tags are dropped, leaving their text. Lines are
+// dedented first so that HTML source indentation does not turn cell text
+// into indented code blocks. Empty cells receive an empty paragraph, like
+// empty GFM cells. Diagnostics for the cell content are reported at the
+// position of htmlBlock, the HTML block holding the table.
+func (c *converter) convertHTMLCellContent(htmlBlock ast.Node, inner string) []Node {
+	// Start nested tables on a line of their own so that they are parsed
+	// as HTML blocks rather than inline tags.
+	inner = nestedTablePattern.ReplaceAllString(inner, "\n$0")
+	lines := strings.Split(inner, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	sub := &converter{
+		source:             []byte(strings.TrimSpace(strings.Join(lines, "\n"))),
+		cfg:                c.cfg,
+		collectDiagnostics: c.collectDiagnostics,
+		depth:              c.depth,
+		stats:              c.stats,
+	}
+	// Cell content is not top-level, and its lines would be relative to the
+	// cell.
+	sub.cfg.sourceMapping = false
+	var content []Node
+	for _, block := range sub.convertChildren(sub.parse()) {
+		if block["type"] == "table" {
+			content = append(content, flattenTable(block)...)
+			continue
+		}
+		content = append(content, block)
+	}
+	if len(sub.diagnostics) > 0 {
+		// Positions within the cell mean nothing in the document.
+		line, column := c.position(htmlBlock)
+		for _, d := range sub.diagnostics {
+			d.Line, d.Column = line, column
+			c.diagnostics = append(c.diagnostics, d)
+		}
+	}
+	if len(content) == 0 {
+		return []Node{{"type": "paragraph", "content": []Node{}}}
+	}
+	return content
+}
+
+// nestedCellSeparator separates the cells of a row in a table flattened by
+// [flattenTable].
+const nestedCellSeparator = " | "
+
+// flattenTable turns a table nested in a table cell, which the ADF schema
+// does not allow, into one paragraph per row. The inline content of the
+// row's cells is joined with " | "; the blocks within a cell are separated
+// by line breaks. Rows without content are dropped.
+func flattenTable(table Node) []Node {
+	var paragraphs []Node
+	rows, _ := table["content"].([]Node)
+	for _, row := range rows {
+		var line []Node
+		cells, _ := row["content"].([]Node)
+		for _, cell := range cells {
+			inline := cellInline(cell["content"].([]Node))
+			if len(inline) == 0 {
+				continue
+			}
+			if len(line) > 0 {
+				line = append(line, newTextNode(nestedCellSeparator, nil))
+			}
+			line = append(line, inline...)
+		}
+		if len(line) > 0 {
+			paragraphs = append(paragraphs, Node{"type": "paragraph", "content": mergeTextNodes(line)})
+		}
+	}
+	return paragraphs
+}
+
+// inlineTypes lists the ADF inline node types the converter produces.
+var inlineTypes = map[string]bool{
+	"text":       true,
+	"hardBreak":  true,
+	"inlineCard": true,
+	"date":       true,
+	"emoji":      true,
+}
+
+// cellInline collects the inline nodes of blocks, separating the content of
+// consecutive blocks with a "hardBreak". Nodes that are neither inline nor
+// hold content, such as rules and media, are dropped.
+func cellInline(blocks []Node) []Node {
+	var inline []Node
+	for _, node := range blocks {
+		if isInlineNode(node) {
+			inline = append(inline, node)
+			continue
+		}
+		children, _ := node["content"].([]Node)
+		if content := cellInline(children); len(content) > 0 {
+			if len(inline) > 0 && inline[len(inline)-1]["type"] != "hardBreak" {
+				inline = append(inline, Node{"type": "hardBreak"})
+			}
+			inline = append(inline, content...)
+		}
+	}
+	return inline
+}
@@ -0,0 +1,458 @@
+package md2adf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseHTMLTag(t *testing.T) {
+	tests := []struct {
+		raw     string
+		name    string
+		closing bool
+		ok      bool
+	}{
+		{"<kbd>", "kbd", false, true},
+		{"</kbd>", "kbd", true, true},
+		{"<KBD>", "kbd", false, true},
+		{"<br/>", "br", false, true},
+		{"<br />", "br", false, true},
+		{`<span class="x">`, "span", false, true},
+		{"<!-- comment -->", "", false, false},
+		{"kbd", "", false, false},
+		{"<>", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			name, closing, ok := parseHTMLTag(tt.raw)
+			if name != tt.name || closing != tt.closing || ok != tt.ok {
+				t.Errorf("parseHTMLTag(%q) = (%q, %v, %v), want (%q, %v, %v)",
+					tt.raw, name, closing, ok, tt.name, tt.closing, tt.ok)
+			}
+		})
+	}
+}
+
+func TestConvert_KbdAsCode(t *testing.T) {
+	t.Run("key combination", func(t *testing.T) {
+		result := Convert("Press <kbd>Ctrl</kbd>+<kbd>C</kbd> to copy", WithKbdAsCode())
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 5 {
+			t.Fatalf("expected 5 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Press ")
+		assertMarks(t, paraContent[0])
+		assertText(t, paraContent[1], "Ctrl")
+		assertMarks(t, paraContent[1], "code")
+		assertText(t, paraContent[2], "+")
+		assertMarks(t, paraContent[2])
+		assertText(t, paraContent[3], "C")
+		assertMarks(t, paraContent[3], "code")
+		assertText(t, paraContent[4], " to copy")
+		assertMarks(t, paraContent[4])
+	})
+
+	t.Run("inside bold", func(t *testing.T) {
+		result := Convert("**hit <kbd>Esc</kbd>**", WithKbdAsCode())
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[1], "Esc")
+		assertMarks(t, paraContent[1], "code", "strong")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := Convert("Press <kbd>Ctrl</kbd>+<kbd>C</kbd>")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Press Ctrl+C")
+	})
+}
+
+func TestConvert_SupSubTags(t *testing.T) {
+	subsup := func(t *testing.T, node Node, want string) {
+		t.Helper()
+		marks, _ := node["marks"].([]Node)
+		for _, m := range marks {
+			if m["type"] == "subsup" {
+				if got := m["attrs"].(Node)["type"]; got != want {
+					t.Errorf("expected subsup type %q, got %v", want, got)
+				}
+				return
+			}
+		}
+		t.Errorf("expected subsup mark on %v", node)
+	}
+
+	t.Run("superscript", func(t *testing.T) {
+		result, diags := ConvertWithDiagnostics("E = mc<sup>2</sup> here")
+		if len(diags) != 0 {
+			t.Errorf("expected no diagnostics, got %v", diags)
+		}
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "E = mc")
+		assertMarks(t, paraContent[0])
+		assertText(t, paraContent[1], "2")
+		assertMarks(t, paraContent[1], "subsup")
+		subsup(t, paraContent[1], "sup")
+		assertText(t, paraContent[2], " here")
+		assertMarks(t, paraContent[2])
+	})
+
+	t.Run("subscript", func(t *testing.T) {
+		result := Convert("H<sub>2</sub>O")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[1], "2")
+		subsup(t, paraContent[1], "sub")
+		assertText(t, paraContent[2], "O")
+		assertMarks(t, paraContent[2])
+	})
+
+	t.Run("uppercase tags with attributes", func(t *testing.T) {
+		result := Convert(`x<SUP class="n">n</SUP>`)
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[1], "n")
+		subsup(t, paraContent[1], "sup")
+	})
+
+	t.Run("inside bold", func(t *testing.T) {
+		result := Convert("**x<sup>2</sup>**")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[1], "2")
+		assertMarks(t, paraContent[1], "strong", "subsup")
+	})
+
+	t.Run("sub nested in sup", func(t *testing.T) {
+		result := Convert("a<sup>b<sub>c</sub>d</sup>")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 4 {
+			t.Fatalf("expected 4 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[1], "b")
+		subsup(t, paraContent[1], "sup")
+		assertText(t, paraContent[2], "c")
+		assertMarks(t, paraContent[2], "subsup")
+		subsup(t, paraContent[2], "sub")
+		// </sub> restores the outer sup.
+		assertText(t, paraContent[3], "d")
+		subsup(t, paraContent[3], "sup")
+
+		// Without </sup>, the unclosed <sup> is skipped.
+		result = Convert("a<sup>b<sub>c</sub>d")
+		paraContent = result["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "ab")
+		assertMarks(t, paraContent[0])
+		subsup(t, paraContent[1], "sub")
+		assertText(t, paraContent[2], "d")
+		assertMarks(t, paraContent[2])
+	})
+}
+
+func TestConvert_HTMLTable(t *testing.T) {
+	input := `Intro
+
+<table>
+  <thead>
+    <tr><th>Name</th><th>Role</th></tr>
+  </thead>
+  <tbody>
+    <tr>
+      <td>**Alice**</td>
+      <td>Lead</td>
+    </tr>
+    <tr><td colspan="2">Spans <b>both</b></td></tr>
+    <tr><td rowspan=2>tall<td>short
+  </tbody>
+</table>
+`
+	result := Convert(input)
+	content := result["content"].([]Node)
+	if len(content) != 2 {
+		t.Fatalf("expected paragraph + table, got %d nodes", len(content))
+	}
+	table := content[1]
+	assertType(t, table, "table")
+
+	rows := table["content"].([]Node)
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(rows))
+	}
+
+	header := rows[0]["content"].([]Node)
+	if len(header) != 2 {
+		t.Fatalf("expected 2 header cells, got %d", len(header))
+	}
+	assertType(t, header[0], "tableHeader")
+	assertText(t, header[0]["content"].([]Node)[0]["content"].([]Node)[0], "Name")
+
+	data := rows[1]["content"].([]Node)
+	assertType(t, data[0], "tableCell")
+	bold := data[0]["content"].([]Node)[0]["content"].([]Node)[0]
+	assertText(t, bold, "Alice")
+	assertMarks(t, bold, "strong")
+
+	spanned := rows[2]["content"].([]Node)
+	if len(spanned) != 1 {
+		t.Fatalf("expected 1 spanning cell, got %d", len(spanned))
+	}
+	if colspan := spanned[0]["attrs"].(Node)["colspan"]; colspan != 2 {
+		t.Errorf("expected colspan 2, got %v", colspan)
+	}
+	assertText(t, spanned[0]["content"].([]Node)[0]["content"].([]Node)[0], "Spans both")
+
+	implicit := rows[3]["content"].([]Node)
+	if len(implicit) != 2 {
+		t.Fatalf("expected 2 implicitly closed cells, got %d", len(implicit))
+	}
+	if rowspan := implicit[0]["attrs"].(Node)["rowspan"]; rowspan != 2 {
+		t.Errorf("expected rowspan 2, got %v", rowspan)
+	}
+	assertText(t, implicit[1]["content"].([]Node)[0]["content"].([]Node)[0], "short")
+}
+
+func TestConvert_HTMLTableColumnWidths(t *testing.T) {
+	input := `<table>
+<colgroup><col style="width: 120.5px;"/><col width="80"/><col/></colgroup>
+<tr><th>A</th><th>B</th><th width="50px">C</th></tr>
+<tr><td rowspan="2">1</td><td colspan="2">2</td></tr>
+<tr><td>3</td><td width="10%">4</td></tr>
+</table>`
+	table := Convert(input)["content"].([]Node)[0]
+	assertType(t, table, "table")
+
+	want := [][]string{
+		{"[120.5]", "[80]", "[50]"},
+		{"[120.5]", "[80 50]"},
+		// The rowspan of "1" shifts these cells to the second and third column.
+		{"[80]", "[50]"},
+	}
+	rows := table["content"].([]Node)
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got %d", len(want), len(rows))
+	}
+	for r, row := range rows {
+		cells := row["content"].([]Node)
+		if len(cells) != len(want[r]) {
+			t.Fatalf("row %d: expected %d cells, got %d", r, len(want[r]), len(cells))
+		}
+		for i, cell := range cells {
+			if got := fmt.Sprint(cell["attrs"].(Node)["colwidth"]); got != want[r][i] {
+				t.Errorf("row %d cell %d: expected colwidth %s, got %s", r, i, want[r][i], got)
+			}
+		}
+	}
+
+	t.Run("partial widths", func(t *testing.T) {
+		table := Convert(`<table><tr><td>a</td><td width="40">b</td></tr><tr><td colspan="2">c</td></tr></table>`)["content"].([]Node)[0]
+		rows := table["content"].([]Node)
+		if _, ok := rows[0]["content"].([]Node)[0]["attrs"]; ok {
+			t.Errorf("expected no attrs for a column without width")
+		}
+		colwidth := rows[0]["content"].([]Node)[1]["attrs"].(Node)["colwidth"]
+		if fmt.Sprint(colwidth) != "[40]" {
+			t.Errorf("expected colwidth [40], got %v", colwidth)
+		}
+		if attrs := rows[1]["content"].([]Node)[0]["attrs"].(Node); attrs["colwidth"] != nil {
+			t.Errorf("expected no colwidth for a cell spanning a column without width, got %v", attrs)
+		}
+	})
+
+	t.Run("gfm table", func(t *testing.T) {
+		table := Convert("| a | b |\n|---|---|\n| c | d |")["content"].([]Node)[0]
+		for _, row := range table["content"].([]Node) {
+			for _, cell := range row["content"].([]Node) {
+				if _, ok := cell["attrs"]; ok {
+					t.Errorf("expected no cell attrs, got %v", cell["attrs"])
+				}
+			}
+		}
+	})
+}
+
+func TestConvert_HTMLNestedTable(t *testing.T) {
+	input := `<table>
+  <tr>
+    <td>
+      Summary
+      <table>
+        <tr><th>Key</th><th>Value</th></tr>
+        <tr><td>**a**</td><td>1<br>2</td></tr>
+        <tr><td></td><td></td></tr>
+        <tr><td></td><td>only</td></tr>
+      </table>
+    </td>
+    <td>Other</td>
+  </tr>
+</table>`
+	table := Convert(input)["content"].([]Node)[0]
+	assertType(t, table, "table")
+	if n := len(collectNodes(table, "table")); n != 1 {
+		t.Fatalf("expected no nested table, got %d tables", n)
+	}
+
+	cells := table["content"].([]Node)[0]["content"].([]Node)
+	if len(cells) != 2 {
+		t.Fatalf("expected 2 cells, got %d", len(cells))
+	}
+	content := cells[0]["content"].([]Node)
+	want := []string{"Summary", "Key | Value", "a | 1\n2", "only"}
+	if len(content) != len(want) {
+		t.Fatalf("expected %d paragraphs, got %d: %v", len(want), len(content), content)
+	}
+	for i, para := range content {
+		assertType(t, para, "paragraph")
+		var sb strings.Builder
+		for _, node := range para["content"].([]Node) {
+			if node["type"] == "hardBreak" {
+				sb.WriteString("\n")
+			}
+			if text, ok := node["text"].(string); ok {
+				sb.WriteString(text)
+			}
+		}
+		if sb.String() != want[i] {
+			t.Errorf("paragraph %d: expected %q, got %q", i, want[i], sb.String())
+		}
+	}
+	assertMarks(t, content[2]["content"].([]Node)[0], "strong")
+
+	t.Run("inline with text", func(t *testing.T) {
+		cell := Convert("<table><tr><td>Before<table><tr><td>x</td><td>y</td></tr></table></td></tr></table>")["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)[0]
+		content := cell["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 paragraphs, got %d: %v", len(content), content)
+		}
+		assertText(t, content[0]["content"].([]Node)[0], "Before")
+		assertText(t, content[1]["content"].([]Node)[0], "x | y")
+	})
+}
+
+func TestConvert_HTMLBlockNonTableSkipped(t *testing.T) {
+	result := Convert("<div>\nhello\n</div>\n\nAfter")
+	content := result["content"].([]Node)
+	if len(content) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(content))
+	}
+	assertType(t, content[0], "paragraph")
+}
+
+func TestConvert_HTMLRule(t *testing.T) {
+	for _, tag := range []string{"<hr>", "<hr/>", "<hr />", `<HR class="sep">`} {
+		t.Run(tag, func(t *testing.T) {
+			result, diags := ConvertWithDiagnostics("Before\n\n" + tag + "\n\nAfter")
+			if len(diags) != 0 {
+				t.Errorf("expected no diagnostics, got %v", diags)
+			}
+			content := result["content"].([]Node)
+			if len(content) != 3 {
+				t.Fatalf("expected 3 nodes, got %d", len(content))
+			}
+			assertType(t, content[0], "paragraph")
+			assertType(t, content[1], "rule")
+			assertType(t, content[2], "paragraph")
+		})
+	}
+
+	t.Run("with other HTML", func(t *testing.T) {
+		content := Convert("<hr><div>x</div>\n\nAfter")["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+	})
+}
+
+func TestConvert_HTMLTableEmptyCell(t *testing.T) {
+	result := Convert("<table><tr><td></td><td>x</td></tr></table>")
+	cells := result["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)
+	para := cells[0]["content"].([]Node)[0]
+	assertType(t, para, "paragraph")
+	if n := len(para["content"].([]Node)); n != 0 {
+		t.Errorf("expected empty paragraph, got %d nodes", n)
+	}
+}
+
+func TestConvertWithDiagnostics_HTMLTableCell(t *testing.T) {
+	// Content dropped inside a cell is reported at the table's position.
+	_, diags := ConvertWithDiagnostics("Intro\n\n<table><tr><td>a <span>b</span></td></tr></table>")
+	want := []string{
+		`3:1: inline raw HTML "<span>" skipped`,
+		`3:1: inline raw HTML "</span>" skipped`,
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), diags)
+	}
+	for i, w := range want {
+		if got := diags[i].String(); got != w {
+			t.Errorf("diagnostic %d: expected %q, got %q", i, w, got)
+		}
+	}
+
+	if _, diags := ConvertWithDiagnostics("<table><tr><td>a **b**</td></tr></table>"); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestConvert_HTMLComments(t *testing.T) {
+	doc, diags := ConvertWithDiagnostics("Before\n\n<!-- internal note\nspanning lines -->\n\nAfter <!-- inline --> text")
+	content := doc["content"].([]Node)
+	if len(content) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(content))
+	}
+	assertText(t, content[0]["content"].([]Node)[0], "Before")
+	assertText(t, content[1]["content"].([]Node)[0], "After  text")
+	if len(diags) != 0 {
+		t.Errorf("expected comments to be dropped silently, got %v", diags)
+	}
+}
+
+func TestConvert_CommentDirectives(t *testing.T) {
+	const input = "Public intro\n\n" +
+		"<!-- adf:ignore-start -->\n" +
+		"## Internal\n\nSecret notes\n\n- private item\n" +
+		"<!-- adf:ignore-end -->\n\n" +
+		"Public end with <!-- adf:ignore-start -->hidden **words** <!-- adf:ignore-end -->visible"
+
+	t.Run("section excluded", func(t *testing.T) {
+		content := Convert(input, WithCommentDirectives())["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(content))
+		}
+		assertText(t, content[0]["content"].([]Node)[0], "Public intro")
+		paraContent := content[1]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Public end with visible")
+	})
+
+	t.Run("unterminated ignores rest of container", func(t *testing.T) {
+		content := Convert("> quoted\n>\n> <!-- adf:ignore-start -->\n>\n> hidden\n\nshown", WithCommentDirectives())["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(content))
+		}
+		if n := len(content[0]["content"].([]Node)); n != 1 {
+			t.Errorf("expected 1 node in blockquote, got %d", n)
+		}
+		assertText(t, content[1]["content"].([]Node)[0], "shown")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		content := Convert(input)["content"].([]Node)
+		if len(content) != 5 {
+			t.Fatalf("expected 5 nodes, got %d", len(content))
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package md2adf
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// balancedLinkifyParser wraps goldmark's linkify parser to apply the GFM
+// rule for trailing parentheses consistently. GFM leaves a trailing ")" out
+// of a bare URL when the URL holds more closing than opening parentheses,
+// but goldmark only checks this when the ")" is the very last character, so
+// in "(see https://example.com/a)." the URL kept the ")". The wrapper trims
+// such parentheses, along with any punctuation they uncover, and leaves
+// them to be parsed as text.
+type balancedLinkifyParser struct {
+	parser.InlineParser
+}
+
+func (p *balancedLinkifyParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, pos := block.Position()
+	node := p.InlineParser.Parse(parent, block, pc)
+	link, ok := node.(*ast.AutoLink)
+	if !ok || link.AutoLinkType != ast.AutoLinkURL {
+		return node
+	}
+	label := link.Label(block.Source())
+	trimmed := trimURLTail(label)
+	if len(trimmed) == len(label) {
+		return node
+	}
+
+	// The linkify parser consumes one leading trigger character that is
+	// not part of the URL, as seen in its Trigger list.
+	start := pos.Start
+	if bytes.IndexByte([]byte(" *_~("), block.Source()[start]) >= 0 {
+		start++
+	}
+	fixed := ast.NewAutoLink(ast.AutoLinkURL, ast.NewTextSegment(text.NewSegment(start, start+len(trimmed))))
+	fixed.Protocol = link.Protocol
+	block.SetPosition(line, pos)
+	block.Advance(start - pos.Start + len(trimmed))
+	return fixed
+}
+
+// trimURLTail strips unbalanced closing parentheses and the trailing
+// punctuation GFM excludes from bare URLs from the end of url.
+func trimURLTail(url []byte) []byte {
+	for len(url) > 0 {
+		switch last := url[len(url)-1]; {
+		case last == ')' && bytes.Count(url, []byte(")")) > bytes.Count(url, []byte("(")):
+		case bytes.IndexByte([]byte("?!.,:*_~"), last) >= 0:
+		default:
+			return url
+		}
+		url = url[:len(url)-1]
+	}
+	return url
+}
+
+// linkifyExtension registers [balancedLinkifyParser] in place of goldmark's
+// [extension.Linkify], at the same priority.
+type linkifyExtension struct{}
+
+func (e linkifyExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(
+			util.Prioritized(&balancedLinkifyParser{extension.NewLinkifyParser()}, 999),
+		),
+	)
+}
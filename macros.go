@@ -0,0 +1,159 @@
+package md2adf
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindExtensionBlock is the goldmark node kind for extension blocks.
+var kindExtensionBlock = ast.NewNodeKind("ExtensionBlock")
+
+// extensionBlock is a Confluence macro written as a ":::extension{...}"
+// fenced block. Its children are the blocks of the macro body.
+type extensionBlock struct {
+	ast.BaseBlock
+	params map[string]string
+	// nested counts the fenced blocks opened inside the body, whose ":::"
+	// closing lines do not close this block.
+	nested int
+	closed bool
+}
+
+// Kind implements [ast.Node].
+func (n *extensionBlock) Kind() ast.NodeKind { return kindExtensionBlock }
+
+// Dump implements [ast.Node].
+func (n *extensionBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, n.params, nil)
+}
+
+var (
+	// extensionFencePattern matches the opening line of an extension block
+	// and captures its parameters.
+	extensionFencePattern = regexp.MustCompile(`^:::extension\{([^}]*)\}[ \t]*$`)
+	// extensionParamPattern matches a key=value parameter whose value may be
+	// double- or single-quoted.
+	extensionParamPattern = regexp.MustCompile(`([A-Za-z_][\w.-]*)[ \t]*=[ \t]*(?:"([^"]*)"|'([^']*)'|([^\s"',]+))`)
+	// nestedFencePattern matches the opening line of any other ::: block.
+	nestedFencePattern = regexp.MustCompile(`^:::[A-Za-z]`)
+)
+
+// defaultExtensionType is the "extensionType" of Confluence macros.
+const defaultExtensionType = "com.atlassian.confluence.macro.core"
+
+// parseExtensionParams parses the key=value pairs of an extension block
+// opening line. Separating commas and anything that is not a key=value pair
+// are ignored.
+func parseExtensionParams(src []byte) map[string]string {
+	params := map[string]string{}
+	for _, m := range extensionParamPattern.FindAllSubmatch(src, -1) {
+		params[string(m[1])] = string(m[2]) + string(m[3]) + string(m[4])
+	}
+	return params
+}
+
+// extensionBlockParser parses Confluence macros fenced by a
+// ":::extension{key=name ...}" line and a ":::" line. The lines between
+// them are parsed as Markdown and form the macro body. An unclosed block
+// runs to the end of its container.
+type extensionBlockParser struct{}
+
+func (p *extensionBlockParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+func (p *extensionBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 {
+		return nil, parser.NoChildren
+	}
+	m := extensionFencePattern.FindSubmatch(util.TrimRightSpace(line[pos:]))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	params := parseExtensionParams(m[1])
+	if params["key"] == "" {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceToEOL()
+	return &extensionBlock{params: params}, parser.HasChildren
+}
+
+func (p *extensionBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	block := node.(*extensionBlock)
+	if block.closed {
+		return parser.Close
+	}
+	line, _ := reader.PeekLine()
+	trimmed := util.TrimLeftSpace(util.TrimRightSpace(line))
+	switch {
+	case bytes.Equal(trimmed, decisionFenceClose) && block.nested == 0:
+		reader.AdvanceToEOL()
+		block.closed = true
+		return parser.Close
+	case bytes.Equal(trimmed, decisionFenceClose):
+		block.nested--
+	case nestedFencePattern.Match(trimmed):
+		block.nested++
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+func (p *extensionBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *extensionBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *extensionBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// convertExtensionBlock converts an extension block into an ADF
+// "extension" node, or a "bodiedExtension" if its body has content. The
+// "key" parameter becomes the "extensionKey" and an optional "type"
+// parameter the "extensionType", which defaults to Confluence's core
+// macros; all other parameters are passed on as Confluence macro
+// parameters.
+func (c *converter) convertExtensionBlock(block *extensionBlock) Node {
+	extensionType := block.params["type"]
+	if extensionType == "" {
+		extensionType = defaultExtensionType
+	}
+	macroParams := Node{}
+	for name, value := range block.params {
+		if name != "key" && name != "type" {
+			macroParams[name] = Node{"value": value}
+		}
+	}
+	node := Node{
+		"type": "extension",
+		"attrs": Node{
+			"extensionType": extensionType,
+			"extensionKey":  block.params["key"],
+			"parameters":    Node{"macroParams": macroParams},
+			"layout":        "default",
+		},
+	}
+	if content := c.convertChildren(block); len(content) > 0 {
+		node["type"] = "bodiedExtension"
+		node["content"] = content
+	}
+	return node
+}
+
+// macroExtension registers the extension block parser with goldmark.
+type macroExtension struct{}
+
+func (e macroExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&extensionBlockParser{}, 750)),
+	)
+}
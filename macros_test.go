@@ -0,0 +1,87 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_Extension(t *testing.T) {
+	node := Convert(":::extension{key=toc}\n:::\n\nAfter")["content"].([]Node)[0]
+	assertType(t, node, "extension")
+	if _, ok := node["content"]; ok {
+		t.Errorf("expected no content, got %v", node["content"])
+	}
+	attrs := node["attrs"].(Node)
+	if attrs["extensionKey"] != "toc" || attrs["extensionType"] != "com.atlassian.confluence.macro.core" {
+		t.Errorf("unexpected attrs %v", attrs)
+	}
+	if params := attrs["parameters"].(Node)["macroParams"].(Node); len(params) != 0 {
+		t.Errorf("expected no macro params, got %v", params)
+	}
+}
+
+func TestConvert_BodiedExtension(t *testing.T) {
+	input := ":::extension{key=expand title=\"Release details\"}\nStarts **Monday**.\n\n:::decision\nShip it\n:::\n:::\n\nAfter"
+	content := Convert(input)["content"].([]Node)
+	if len(content) != 2 {
+		t.Fatalf("expected bodiedExtension and paragraph, got %d nodes", len(content))
+	}
+	node := content[0]
+	assertType(t, node, "bodiedExtension")
+	attrs := node["attrs"].(Node)
+	if attrs["extensionKey"] != "expand" {
+		t.Errorf("expected key expand, got %v", attrs["extensionKey"])
+	}
+	title := attrs["parameters"].(Node)["macroParams"].(Node)["title"]
+	if title == nil || title.(Node)["value"] != "Release details" {
+		t.Errorf("expected title param, got %v", title)
+	}
+
+	// The nested :::decision block keeps its own closing line.
+	body := node["content"].([]Node)
+	if len(body) != 2 {
+		t.Fatalf("expected 2 body blocks, got %d", len(body))
+	}
+	assertType(t, body[0], "paragraph")
+	assertMarks(t, body[0]["content"].([]Node)[1], "strong")
+	assertType(t, body[1], "decisionList")
+	assertText(t, content[1]["content"].([]Node)[0], "After")
+}
+
+func TestParseExtensionParams(t *testing.T) {
+	tests := []struct {
+		src  string
+		want map[string]string
+	}{
+		{"key=toc", map[string]string{"key": "toc"}},
+		{`key=toc, maxLevel = 3 style='disc' type="x.y"`, map[string]string{"key": "toc", "maxLevel": "3", "style": "disc", "type": "x.y"}},
+		{"key=toc =bad flag 'x'", map[string]string{"key": "toc"}},
+		{"", map[string]string{}},
+	}
+	for _, tt := range tests {
+		got := parseExtensionParams([]byte(tt.src))
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.src, tt.want, got)
+			continue
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("%q: expected %s=%q, got %q", tt.src, k, v, got[k])
+			}
+		}
+	}
+}
+
+func TestConvert_ExtensionInvalid(t *testing.T) {
+	for _, md := range []string{":::extension{title=x}\n:::", ":::extension\n:::", "text :::extension{key=toc}", "\\:::extension{key=toc}\n:::"} {
+		content := Convert(md)["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("%q: expected 1 node, got %d", md, len(content))
+		}
+		assertType(t, content[0], "paragraph")
+	}
+
+	t.Run("custom type", func(t *testing.T) {
+		attrs := Convert(":::extension{key=chart type=com.example.charts}\n:::")["content"].([]Node)[0]["attrs"].(Node)
+		if attrs["extensionType"] != "com.example.charts" {
+			t.Errorf("expected custom extensionType, got %v", attrs["extensionType"])
+		}
+	})
+}
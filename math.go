@@ -0,0 +1,151 @@
+package md2adf
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindInlineMath is the goldmark node kind for $...$ spans.
+var kindInlineMath = ast.NewNodeKind("InlineMath")
+
+// inlineMath is an inline $...$ span. Segment covers the math source between
+// the dollar signs.
+type inlineMath struct {
+	ast.BaseInline
+	Segment text.Segment
+}
+
+// Kind implements [ast.Node].
+func (n *inlineMath) Kind() ast.NodeKind { return kindInlineMath }
+
+// Dump implements [ast.Node].
+func (n *inlineMath) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Value": string(n.Segment.Value(source))}, nil)
+}
+
+// kindMathBlock is the goldmark node kind for $$...$$ display math.
+var kindMathBlock = ast.NewNodeKind("MathBlock")
+
+// mathBlock is a display math block delimited by $$ lines. Its lines hold the
+// math source without the delimiters.
+type mathBlock struct {
+	ast.BaseBlock
+	closed bool
+}
+
+// Kind implements [ast.Node].
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+// IsRaw implements [ast.Node].
+func (n *mathBlock) IsRaw() bool { return true }
+
+// Dump implements [ast.Node].
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// inlineMathParser parses $...$ spans. Following Pandoc, the opening dollar
+// must not be followed by whitespace and the closing dollar must not be
+// preceded by whitespace or followed by a digit, so prices such as
+// "$5 and $10" stay literal.
+type inlineMathParser struct{}
+
+func (p *inlineMathParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *inlineMathParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	if len(line) < 3 || line[1] == '$' || util.IsSpace(line[1]) {
+		return nil
+	}
+	for i := 2; i < len(line); i++ {
+		if line[i] != '$' || line[i-1] == '\\' || util.IsSpace(line[i-1]) {
+			continue
+		}
+		if i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9' {
+			continue
+		}
+		node := &inlineMath{Segment: text.NewSegment(segment.Start+1, segment.Start+i)}
+		block.Advance(i + 1)
+		return node
+	}
+	return nil
+}
+
+// mathBlockParser parses display math delimited by lines starting and ending
+// with $$. Both the single-line form "$$x$$" and multi-line blocks are
+// supported.
+type mathBlockParser struct{}
+
+var mathDelimiter = []byte("$$")
+
+func (p *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathDelimiter) {
+		return nil, parser.NoChildren
+	}
+	node := &mathBlock{}
+	start := segment.Start - segment.Padding + pos + len(mathDelimiter)
+	rest := util.TrimRightSpace(line[pos+len(mathDelimiter):])
+	if bytes.HasSuffix(rest, mathDelimiter) {
+		// Single-line form: $$x$$
+		if stop := start + len(rest) - len(mathDelimiter); stop > start {
+			node.Lines().Append(text.NewSegment(start, stop))
+		}
+		node.closed = true
+	} else if len(rest) > 0 {
+		node.Lines().Append(text.NewSegment(start, segment.Stop))
+	}
+	reader.AdvanceToEOL()
+	return node, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	block := node.(*mathBlock)
+	if block.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if trimmed := util.TrimRightSpace(line); bytes.HasSuffix(trimmed, mathDelimiter) {
+		if content := trimmed[:len(trimmed)-len(mathDelimiter)]; !util.IsBlank(content) {
+			node.Lines().Append(text.NewSegment(segment.Start, segment.Start+len(content)))
+		}
+		reader.AdvanceToEOL()
+		block.closed = true
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.AdvanceToEOL()
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// mathExtension registers the inline and display math parsers with goldmark.
+type mathExtension struct{}
+
+func (e mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 750)),
+		parser.WithInlineParsers(util.Prioritized(&inlineMathParser{}, 600)),
+	)
+}
@@ -0,0 +1,88 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_InlineMath(t *testing.T) {
+	const input = "Energy $E=mc^2$ holds"
+
+	t.Run("code", func(t *testing.T) {
+		paraContent := Convert(input, WithInlineMath(InlineMathCode))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Energy ")
+		assertText(t, paraContent[1], "E=mc^2")
+		assertMarks(t, paraContent[1], "code")
+		assertText(t, paraContent[2], " holds")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		paraContent := Convert(input, WithInlineMath(InlineMathText))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Energy E=mc^2 holds")
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		paraContent := Convert(input, WithInlineMath(InlineMathDrop))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Energy  holds")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		paraContent := Convert(input)["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[0], input)
+	})
+
+	t.Run("marks are inherited", func(t *testing.T) {
+		paraContent := Convert("**see $x$**", WithInlineMath(InlineMathCode))["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[1], "x")
+		assertMarks(t, paraContent[1], "code", "strong")
+	})
+}
+
+func TestConvert_InlineMathLiteralDollars(t *testing.T) {
+	inputs := []string{
+		"It costs $5 and $10 total",
+		"Escaped \\$x\\$ stays",
+		"A lone $ sign",
+	}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			result := Convert(input, WithInlineMath(InlineMathCode))
+			for _, n := range result["content"].([]Node)[0]["content"].([]Node) {
+				if _, ok := n["marks"]; ok {
+					t.Errorf("expected no math span, got %v", n)
+				}
+			}
+		})
+	}
+}
+
+func TestConvert_DisplayMath(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		code  string
+	}{
+		{"multi line", "Before\n\n$$\n\\int_0^1 x\\,dx\n$$\n\nAfter", "\\int_0^1 x\\,dx"},
+		{"single line", "$$a^2 + b^2 = c^2$$", "a^2 + b^2 = c^2"},
+		{"two lines", "$$\na\nb\n$$", "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Convert(tt.input, WithInlineMath(InlineMathText))
+			blocks := collectNodes(result, "codeBlock")
+			if len(blocks) != 1 {
+				t.Fatalf("expected 1 codeBlock, got %d", len(blocks))
+			}
+			if lang := blocks[0]["attrs"].(Node)["language"]; lang != "latex" {
+				t.Errorf("expected language 'latex', got %v", lang)
+			}
+			assertText(t, blocks[0]["content"].([]Node)[0], tt.code)
+		})
+	}
+}
@@ -6,15 +6,15 @@
 //
 // The conversion pipeline works as follows:
 //
-//  1. Parse the Markdown string using goldmark (with table, strikethrough, and linkify extensions).
+//  1. Parse the Markdown string using goldmark (with table, strikethrough, linkify, and task list extensions).
 //  2. Walk the resulting goldmark AST.
 //  3. Recursively build an ADF node tree from the AST.
 //
 // # Supported Markdown elements
 //
 // Block-level: paragraphs, headings (1-6), bullet lists, ordered lists,
-// nested lists, fenced/indented code blocks, blockquotes, thematic breaks,
-// and tables (with header rows).
+// nested lists, task lists, fenced/indented code blocks, blockquotes,
+// thematic breaks, and tables (with header rows).
 //
 // Inline: bold, italic, strikethrough, inline code, links, autolinks
 // (rendered as ADF inlineCard nodes), images (converted to links), hard
@@ -28,13 +28,21 @@ package md2adf
 
 import (
 	"bytes"
+	"crypto/rand"
 	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	extast "github.com/yuin/goldmark/extension/ast"
 	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
 )
 
 // Node represents a single ADF node as a generic JSON-like map.
@@ -56,160 +64,659 @@ type Node map[string]any
 // produces a valid doc node with an empty content array.
 //
 // The Markdown parser is configured with the goldmark table, strikethrough,
-// and linkify extensions, so GFM-style tables, ~~strikethrough~~, and bare
-// URLs are all recognized.
-func Convert(markdown string) Node {
-	source := []byte(markdown)
-	reader := text.NewReader(source)
-	md := goldmark.New(
-		goldmark.WithExtensions(
-			extension.Table,
-			extension.Strikethrough,
-			extension.Linkify,
-		),
-	)
-	doc := md.Parser().Parse(reader)
+// linkify, and task list extensions, so GFM-style tables, ~~strikethrough~~,
+// bare URLs, and "- [ ]" task items are all recognized.
+//
+// Paragraphs starting with "<> " always become decision lists, ==text== is
+// always highlighted, :::extension{key=...} blocks always become Confluence
+// macros, and "!!! type" admonitions always become panels. These are breaking
+// changes from earlier versions, which kept such text literal; escape the
+// marker, as in "\<> ", "\==", "\:::" or "\!!!", to keep it.
+//
+// Optional behaviour such as [WithHeadingOffset] can be enabled by passing
+// one or more [Option] values.
+//
+// The output is deterministic: converting the same input twice marshals to
+// identical JSON, except for the randomly generated "localId" attrs of task
+// list nodes. Use [WithLocalIDGenerator] to make those reproducible too.
+func Convert(markdown string, opts ...Option) Node {
+	return newConverter([]byte(markdown), opts).convertDocument()
+}
 
-	return Node{
+// ConvertContent transforms a Markdown string into the block-level ADF nodes
+// that [Convert] would place in the "content" array of its doc node, without
+// the surrounding doc wrapper. Use it to inject converted Markdown into a
+// larger ADF tree that is assembled by the caller.
+func ConvertContent(markdown string, opts ...Option) []Node {
+	c := newConverter([]byte(markdown), opts)
+	return c.convertChildren(c.parse())
+}
+
+// ConvertSafe is like [Convert] but recovers from any panic raised during
+// parsing or conversion and reports it as an error instead. It is the
+// recommended entry point for untrusted input, where a malformed document
+// must not take down the calling service.
+func ConvertSafe(markdown string, opts ...Option) (doc Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			doc = nil
+			err = fmt.Errorf("md2adf: conversion panicked: %v", r)
+		}
+	}()
+	return Convert(markdown, opts...), nil
+}
+
+// converter carries the state shared by a single conversion: the Markdown
+// source that AST segments point into and the resolved options.
+type converter struct {
+	source []byte
+	cfg    config
+
+	// collectDiagnostics enables recording of diagnostics via warn.
+	collectDiagnostics bool
+	diagnostics        []Diagnostic
+	// stats collects content counts when non-nil.
+	stats *Stats
+
+	// depth is the current nesting level, bounded by [WithMaxDepth].
+	depth int
+
+	// lineStartOffsets holds the offset of every source line, see
+	// [converter.lineStarts].
+	lineStartOffsets []int
+	// blockEnds records the last source line of blocks located by
+	// [converter.sourceLines] without a recorded position.
+	blockEnds map[ast.Node]int
+}
+
+// newConverter returns a converter for source with opts applied on top of
+// the default configuration.
+func newConverter(source []byte, opts []Option) *converter {
+	c := &converter{source: source}
+	for _, opt := range opts {
+		opt(&c.cfg)
+	}
+	if c.cfg.frontmatter == FrontmatterStrip {
+		if fence, _, body := splitFrontmatter(string(source)); fence != "" {
+			// Blank lines keep diagnostic line numbers intact.
+			skipped := source[:len(source)-len(body)]
+			c.source = append(bytes.Repeat([]byte("\n"), bytes.Count(skipped, []byte("\n"))), body...)
+		}
+	}
+	return c
+}
+
+// convertDocument parses the source and wraps the converted block nodes in a
+// top-level ADF "doc" node.
+func (c *converter) convertDocument() Node {
+	doc := Node{
 		"version": 1,
 		"type":    "doc",
-		"content": convertChildren(doc, source),
+		"content": c.convertChildren(c.parse()),
+	}
+	if c.cfg.omitVersion {
+		delete(doc, "version")
 	}
+	return doc
+}
+
+// parse parses the converter's source into a goldmark AST.
+func (c *converter) parse() ast.Node {
+	md := goldmark.New(goldmark.WithExtensions(c.extensions()...))
+	return md.Parser().Parse(text.NewReader(c.source))
+}
+
+// extensions returns the goldmark extensions used to parse the source. The
+// table, strikethrough, linkify, and task list extensions as well as the
+// {color} directives are always enabled; option dependent syntax such as
+// $math$ is only added when requested so that the default output stays
+// unchanged.
+func (c *converter) extensions() []goldmark.Extender {
+	exts := []goldmark.Extender{
+		extension.Table,
+		strikethroughExtension{singleTilde: c.cfg.singleTildeStrike},
+		linkifyExtension{},
+		extension.TaskList,
+		directiveExtension{},
+		highlightExtension{},
+		decisionExtension{},
+		macroExtension{},
+		admonitionExtension{},
+	}
+	if c.cfg.inlineMath != 0 {
+		exts = append(exts, mathExtension{})
+	}
+	if c.cfg.wikiLinks != nil {
+		exts = append(exts, wikiLinkExtension{})
+	}
+	if c.cfg.alphaLists {
+		exts = append(exts, alphaListExtension{})
+	}
+	return exts
 }
 
 // convertChildren iterates over the direct children of n and converts each
 // one via [convertNode]. Nil results (e.g. empty paragraphs) are silently
-// dropped.
-func convertChildren(n ast.Node, source []byte) []Node {
+// dropped. With [WithExternalMedia], paragraphs are split around their images
+// via [splitMediaParagraph].
+func (c *converter) convertChildren(n ast.Node) []Node {
+	if !c.descend(n) {
+		return []Node{{"type": "paragraph", "content": []Node{newTextNode(truncationMarker, nil)}}}
+	}
+	defer c.ascend()
+
 	var nodes []Node
+	// sources holds the AST node each top-level node was converted from,
+	// or nil for spacers, when source mapping is enabled.
+	var sources []ast.Node
+	mapSource := c.cfg.sourceMapping && n.Kind() == ast.KindDocument
+	add := func(src ast.Node, blocks ...Node) {
+		nodes = append(nodes, blocks...)
+		if mapSource {
+			for range blocks {
+				sources = append(sources, src)
+			}
+		}
+	}
+	// ignoring is set between ignore-start and ignore-end comment directives.
+	var ignoring bool
 	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
-		if node := convertNode(child, source); node != nil {
-			nodes = append(nodes, node)
+		if block, ok := child.(*ast.HTMLBlock); ok {
+			if start, end := c.ignoreDirective(c.htmlBlockText(block)); start || end {
+				ignoring = start
+				continue
+			}
+		}
+		if ignoring || isDefinitionRemnant(child) {
+			continue
+		}
+		if prev := previousBlock(child); c.cfg.preserveEmptyParagraphs && prev != nil {
+			// Extra blank lines between blocks act as spacers.
+			for range c.blankLinesBetween(prev, child) - 1 {
+				add(nil, Node{"type": "paragraph"})
+			}
+		}
+		if node := c.convertNode(child); node != nil {
+			if c.cfg.externalMedia && node["type"] == "paragraph" {
+				// Paragraphs holding images may split into several blocks.
+				add(child, c.splitMedia(node)...)
+				continue
+			}
+			add(child, node)
 		}
 	}
+	if n.Kind() == ast.KindDocument && len(c.cfg.embedCardHosts) > 0 {
+		for i, node := range nodes {
+			nodes[i] = c.promoteEmbedCard(node)
+		}
+	}
+	if n.Kind() == ast.KindDocument {
+		nodes, sources = wrapInline(nodes, sources)
+	}
+	for i, src := range sources {
+		if src != nil {
+			c.annotateSource(nodes[i], src)
+		}
+	}
+	c.recordBlocks(nodes)
 	return nodes
 }
 
+// wrapInline wraps every run of inline nodes among the top-level nodes of a
+// document in a paragraph, as a doc may only hold block nodes. Inline nodes
+// only get there through a converter bug, which would otherwise make Jira
+// reject the whole document. sources, if not nil, holds the AST node each
+// node was converted from; a paragraph keeps the source of its first node.
+// The result is never nil, so that an empty document marshals to an empty
+// content array.
+func wrapInline(nodes []Node, sources []ast.Node) ([]Node, []ast.Node) {
+	blocks := make([]Node, 0, len(nodes))
+	var blockSources []ast.Node
+	for i := 0; i < len(nodes); {
+		block, end := nodes[i], i+1
+		if isInlineNode(block) {
+			for end < len(nodes) && isInlineNode(nodes[end]) {
+				end++
+			}
+			block = Node{"type": "paragraph", "content": slices.Clone(nodes[i:end])}
+		}
+		blocks = append(blocks, block)
+		if sources != nil {
+			blockSources = append(blockSources, sources[i])
+		}
+		i = end
+	}
+	return blocks, blockSources
+}
+
+// isInlineNode reports whether node is one of the [inlineTypes].
+func isInlineNode(node Node) bool {
+	nodeType, _ := node["type"].(string)
+	return inlineTypes[nodeType]
+}
+
+// isDefinitionRemnant reports whether n is the empty text block goldmark
+// leaves behind for a paragraph made up only of link reference definitions.
+// Definitions have no ADF equivalent, so such blocks produce no node, not
+// even an empty paragraph with [WithPreserveEmptyParagraphs].
+func isDefinitionRemnant(n ast.Node) bool {
+	_, ok := n.(*ast.TextBlock)
+	return ok && n.Lines().Len() == 0 && !n.HasChildren()
+}
+
+// previousBlock returns the sibling block before n, skipping definition
+// remnants, or nil if there is none.
+func previousBlock(n ast.Node) ast.Node {
+	prev := n.PreviousSibling()
+	for prev != nil && isDefinitionRemnant(prev) {
+		prev = prev.PreviousSibling()
+	}
+	return prev
+}
+
+// blankLinesBetween counts the blank source lines separating the sibling
+// blocks prev and next. Lines holding only blockquote markers count as
+// blank; lines the AST does not cover, such as code fences, setext
+// underlines, or link reference definitions, do not, and only the longest
+// run of blank lines between them counts, so that a definition between two
+// paragraphs does not add a spacer. It returns 0 when either block has no
+// source range.
+func (c *converter) blankLinesBetween(prev, next ast.Node) int {
+	_, stop, ok := c.nodeRange(prev)
+	if !ok {
+		return 0
+	}
+	start, _, ok := c.nodeRange(next)
+	if !ok || start < stop {
+		return 0
+	}
+	gap := c.source[stop:start]
+	if stop > 0 && c.source[stop-1] != '\n' {
+		// Skip the remainder of prev's last line.
+		i := bytes.IndexByte(gap, '\n')
+		if i < 0 {
+			return 0
+		}
+		gap = gap[i+1:]
+	}
+	lines := bytes.Split(gap, []byte("\n"))
+	// The last element is the prefix of next's first line.
+	blank, run := 0, 0
+	for _, line := range lines[:len(lines)-1] {
+		if len(bytes.Trim(line, " \t>")) > 0 {
+			run = 0
+			continue
+		}
+		run++
+		blank = max(blank, run)
+	}
+	return blank
+}
+
+// truncationMarker replaces block content nested deeper than [WithMaxDepth].
+const truncationMarker = "…"
+
+// descend enters one more nesting level below n. When that would exceed the
+// maximum depth it records a diagnostic and reports false instead; callers
+// then replace the deeper content with a truncation marker or plain text.
+// Every successful descend must be paired with an [converter.ascend].
+func (c *converter) descend(n ast.Node) bool {
+	limit := c.cfg.maxDepth
+	if limit <= 0 {
+		limit = defaultMaxDepth
+	}
+	if c.depth >= limit {
+		c.warn(n, "content nested deeper than %d levels truncated", limit)
+		return false
+	}
+	c.depth++
+	return true
+}
+
+// ascend leaves a nesting level entered with [converter.descend].
+func (c *converter) ascend() {
+	c.depth--
+}
+
 // convertNode maps a single goldmark AST block node to its ADF equivalent.
 //
 // Supported block types:
 //   - [ast.Paragraph] / [ast.TextBlock] → "paragraph"
-//   - [ast.Heading]                     → "heading" (with level attr)
-//   - [ast.List]                        → "bulletList" or "orderedList"
+//   - [ast.Heading]                     → "heading" (with level attr, offset and clamped to 1-6)
+//   - [ast.List]                        → "bulletList", "orderedList" (order attr unless it starts at 1), or "taskList"
 //   - [ast.FencedCodeBlock]             → "codeBlock" (with optional language attr)
 //   - [ast.CodeBlock]                   → "codeBlock" (indented, no language)
-//   - [ast.Blockquote]                  → "blockquote"
+//   - mermaid/plantuml fenced code      → per [WithDiagramMode]
+//   - [ast.Blockquote]                  → "blockquote" ("panel" for GitHub alerts)
 //   - [ast.ThematicBreak]               → "rule"
 //   - [extast.Table]                    → "table"
+//   - [ast.HTMLBlock] holding a <table>  → "table" (other HTML is skipped)
+//   - [ast.HTMLBlock] holding only <hr>  → "rule"
+//   - $$ display math                   → "codeBlock" (language "latex", see [WithInlineMath])
+//   - :::extension{key=…} blocks       → "extension", or "bodiedExtension" with a body
+//   - !!! admonitions                   → "panel" (like GitHub alerts)
 //
 // Unrecognized block types with children fall through: the first converted
 // child is returned so that content is not silently lost. Truly unknown or
-// empty nodes return nil.
-func convertNode(n ast.Node, source []byte) Node {
+// empty nodes return nil. With [WithUnknownAsText], unrecognized blocks are
+// instead emitted verbatim via [converter.sourceParagraph].
+func (c *converter) convertNode(n ast.Node) Node {
 	switch node := n.(type) {
 	case *ast.Paragraph, *ast.TextBlock:
-		content := convertInlineChildren(node, source, nil)
+		content := c.convertInlineChildren(node, nil)
+		if c.isBlankParagraph(content) {
+			content = nil
+		}
 		if len(content) == 0 {
+			if c.cfg.preserveEmptyParagraphs {
+				return Node{"type": "paragraph"}
+			}
 			return nil
 		}
-		return Node{
+		para := Node{
 			"type":    "paragraph",
 			"content": content,
 		}
+		if marks := paragraphMarks(node); len(marks) > 0 {
+			para["marks"] = marks
+		}
+		return para
 
 	case *ast.Heading:
+		// ADF only allows heading levels 1-6, so clamp after applying the offset.
+		level := min(max(node.Level+c.cfg.headingOffset, 1), 6)
+		// Jira rejects hardBreak nodes in headings; setext headings spanning
+		// several lines are joined with spaces instead.
+		content := breaksToSpaces(c.convertInlineChildren(node, nil))
+		if c.cfg.plainHeadings {
+			content = stripFormattingMarks(content)
+		}
+		// A bare "#" marker would leave an empty heading, which renders as
+		// a blank line; drop it like an empty paragraph.
+		if len(content) == 0 {
+			c.warn(node, "empty heading dropped")
+			return nil
+		}
 		return Node{
 			"type":    "heading",
-			"attrs":   Node{"level": node.Level},
-			"content": convertInlineChildren(node, source, nil),
+			"attrs":   Node{"level": level},
+			"content": content,
 		}
 
 	case *ast.List:
-		listType := "bulletList"
-		if node.IsOrdered() {
-			listType = "orderedList"
+		if isTaskList(node) && !c.cfg.taskListAsText {
+			return c.convertTaskList(node)
 		}
-		return Node{
-			"type":    listType,
-			"content": convertListItems(node, source),
+		if !node.IsOrdered() {
+			return Node{
+				"type":    "bulletList",
+				"content": c.convertListItems(node),
+			}
 		}
-
-	case *ast.FencedCodeBlock:
-		var buf bytes.Buffer
-		lines := node.Lines()
-		for i := 0; i < lines.Len(); i++ {
-			line := lines.At(i)
-			buf.Write(line.Value(source))
+		list := Node{
+			"type":    "orderedList",
+			"content": c.convertListItems(node),
 		}
-		// Remove trailing newline if present
-		code := buf.String()
-		if len(code) > 0 && code[len(code)-1] == '\n' {
-			code = code[:len(code)-1]
+		// Lists starting at 1 omit the "order" attr, as that is the default.
+		// CommonMark start numbers have at most nine digits and are never
+		// negative; 0 is a valid ADF order and is kept.
+		if node.Start != 1 {
+			list["attrs"] = Node{"order": node.Start}
 		}
+		return list
 
+	case *ast.FencedCodeBlock:
+		lang := c.codeBlockLanguage(node)
+		if c.cfg.diagramMode != DiagramCode {
+			if diagram := c.convertDiagram(lang, c.codeBlockText(node)); diagram != nil {
+				return diagram
+			}
+		}
 		adfNode := Node{
-			"type": "codeBlock",
-			"content": []Node{
-				{"type": "text", "text": code},
-			},
+			"type":    "codeBlock",
+			"content": codeBlockContent(c.codeBlockText(node)),
 		}
-		if lang := string(node.Language(source)); lang != "" {
+		if lang != "" {
 			adfNode["attrs"] = Node{"language": lang}
 		}
 		return adfNode
 
 	case *ast.CodeBlock:
-		var buf bytes.Buffer
-		lines := node.Lines()
-		for i := 0; i < lines.Len(); i++ {
-			line := lines.At(i)
-			buf.Write(line.Value(source))
-		}
-		code := buf.String()
-		if len(code) > 0 && code[len(code)-1] == '\n' {
-			code = code[:len(code)-1]
+		// Indented code is often accidental, such as text indented below a
+		// list, so migration tools may want to review it.
+		c.warn(node, "indented code block converted without language")
+		return Node{
+			"type":    "codeBlock",
+			"content": codeBlockContent(c.codeBlockText(node)),
 		}
+
+	case *decisionList:
+		return c.convertDecisionList(node)
+
+	case *extensionBlock:
+		return c.convertExtensionBlock(node)
+
+	case *admonition:
+		return c.convertAdmonition(node)
+
+	case *mathBlock:
+		// ADF has no math node, so display math is kept as LaTeX source.
 		return Node{
-			"type": "codeBlock",
-			"content": []Node{
-				{"type": "text", "text": code},
-			},
+			"type":    "codeBlock",
+			"attrs":   Node{"language": "latex"},
+			"content": codeBlockContent(c.codeBlockText(node)),
 		}
 
 	case *ast.Blockquote:
+		if panel := c.convertAlert(node); panel != nil {
+			return panel
+		}
+		// ADF requires content, so blockquotes left empty, such as a bare
+		// ">" or one holding only a link reference definition, are dropped.
+		content := c.convertChildren(node)
+		if len(content) == 0 {
+			return nil
+		}
 		return Node{
 			"type":    "blockquote",
-			"content": convertChildren(node, source),
+			"content": content,
 		}
 
 	case *ast.ThematicBreak:
 		return Node{"type": "rule"}
 
 	case *extast.Table:
-		return convertTable(node, source)
+		return c.convertTable(node)
+
+	case *ast.HTMLBlock:
+		// HTML tables are converted like GFM tables and a lone <hr> like a
+		// thematic break; any other block-level HTML is skipped.
+		raw := c.htmlBlockText(node)
+		if rows, ok := parseHTMLTable(raw); ok {
+			return c.convertHTMLTable(node, rows)
+		}
+		if name, closing, ok := parseHTMLTag(raw); ok && name == "hr" && !closing {
+			return Node{"type": "rule"}
+		}
+		// Comments are hidden by HTML renderers as well, so dropping them
+		// loses nothing worth a diagnostic.
+		if _, ok := htmlComment(raw); !ok {
+			c.warn(node, "raw HTML block skipped")
+			c.record(func(s *Stats) { s.DroppedHTML++ })
+		}
+		return nil
 
 	default:
+		if c.cfg.unknownAsText && n.Type() == ast.TypeBlock {
+			if para := c.sourceParagraph(n); para != nil {
+				c.warn(n, "unsupported %s block kept as source text", n.Kind())
+				return para
+			}
+		}
 		// For unknown block types, try to process children
 		if n.HasChildren() && n.Type() == ast.TypeBlock {
-			children := convertChildren(n, source)
+			children := c.convertChildren(n)
 			if len(children) > 0 {
+				if len(children) > 1 {
+					c.warn(n, "unsupported %s block: kept first of %d children", n.Kind(), len(children))
+				}
 				return children[0] // Return first child for unknown blocks
 			}
 		}
+		c.warn(n, "unsupported %s node dropped", n.Kind())
 		return nil
 	}
 }
 
+// sourceParagraph returns a paragraph holding the original Markdown source of
+// n, with source lines separated by hard breaks, or nil if n has no recorded
+// source position.
+func (c *converter) sourceParagraph(n ast.Node) Node {
+	start, stop, ok := c.nodeRange(n)
+	if !ok {
+		return nil
+	}
+	raw := strings.TrimRight(string(c.source[start:stop]), "\n")
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	var content []Node
+	for i, line := range strings.Split(sanitizeText(raw), "\n") {
+		if i > 0 {
+			content = append(content, Node{"type": "hardBreak"})
+		}
+		if line != "" {
+			content = append(content, Node{"type": "text", "text": line})
+		}
+	}
+	return Node{
+		"type":    "paragraph",
+		"content": content,
+	}
+}
+
+// soleURLCard returns a paragraph holding an "inlineCard" for the list item
+// li if its sole content is a bare URL, i.e. an autolink or linkified URL
+// that a smart link card can resolve.
+func (c *converter) soleURLCard(li *ast.ListItem) (Node, bool) {
+	if li.ChildCount() != 1 {
+		return nil, false
+	}
+	block := li.FirstChild()
+	switch block.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+	default:
+		return nil, false
+	}
+	link, ok := block.FirstChild().(*ast.AutoLink)
+	if !ok || block.ChildCount() != 1 || link.AutoLinkType != ast.AutoLinkURL {
+		return nil, false
+	}
+	href := c.normalizeHref(string(link.URL(c.source)))
+	if !c.isCardURL(href) {
+		return nil, false
+	}
+	return Node{"type": "paragraph", "content": []Node{c.inlineCard(href)}}, true
+}
+
+// promoteEmbedCard replaces a paragraph holding only a bare URL whose host
+// is listed by [WithEmbedCardHosts] with an "embedCard". Other nodes are
+// returned unchanged.
+func (c *converter) promoteEmbedCard(node Node) Node {
+	inline, _ := node["content"].([]Node)
+	if node["type"] != "paragraph" || len(inline) != 1 || inline[0]["type"] != "inlineCard" {
+		return node
+	}
+	href, _ := inline[0]["attrs"].(Node)["url"].(string)
+	u, err := url.Parse(href)
+	if err != nil {
+		return node
+	}
+	if matchHost(u.Hostname(), c.cfg.embedCardHosts) {
+		return Node{
+			"type":  "embedCard",
+			"attrs": Node{"url": href, "layout": "center", "width": 100},
+		}
+	}
+	return node
+}
+
+// matchHost reports whether host is one of hosts, which must be lowercase,
+// or a subdomain of one of them.
+func matchHost(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// codeBlockText concatenates the raw lines of a code-like block node and
+// strips the single trailing newline that goldmark keeps on the last line.
+func (c *converter) codeBlockText(n ast.Node) string {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		buf.Write(line.Value(c.source))
+	}
+	code := buf.String()
+	if len(code) > 0 && code[len(code)-1] == '\n' {
+		code = code[:len(code)-1]
+	}
+	return sanitizeText(code)
+}
+
+// codeBlockContent returns the content of a "codeBlock" node holding code.
+// ADF rejects empty text nodes, so empty code yields an empty content array.
+func codeBlockContent(code string) []Node {
+	if code == "" {
+		return []Node{}
+	}
+	return []Node{{"type": "text", "text": code}}
+}
+
+// codeBlockLanguage extracts the language from a fenced code block's info
+// string. Only the first whitespace-separated token is used, so metadata
+// such as title="main.go" or filename=main.go does not end up in the
+// language attr; a first token that is itself key=value metadata yields no
+// language. The metadata is dropped, as the ADF codeBlock node has no attr
+// to hold it.
+func (c *converter) codeBlockLanguage(n *ast.FencedCodeBlock) string {
+	if n.Info == nil {
+		return ""
+	}
+	fields := strings.Fields(sanitizeText(string(n.Info.Segment.Value(c.source))))
+	if len(fields) == 0 || strings.Contains(fields[0], "=") {
+		return ""
+	}
+	return string(util.UnescapePunctuations([]byte(fields[0])))
+}
+
 // convertListItems converts the children of an [ast.List] into ADF "listItem"
 // nodes. Each list item's block-level content (typically paragraphs and
-// possibly nested lists) is preserved in the item's "content" array.
-func convertListItems(list *ast.List, source []byte) []Node {
+// possibly nested lists) is preserved in the item's "content" array. Items
+// without text, such as one holding only a nested list, get no placeholder
+// paragraph; only a completely empty item receives an empty paragraph, since
+// ADF requires list items to have content.
+func (c *converter) convertListItems(list *ast.List) []Node {
 	var items []Node
 	for child := list.FirstChild(); child != nil; child = child.NextSibling() {
 		if li, ok := child.(*ast.ListItem); ok {
+			if c.cfg.cardInListItems {
+				if card, ok := c.soleURLCard(li); ok {
+					items = append(items, Node{"type": "listItem", "content": []Node{card}})
+					continue
+				}
+			}
 			// List items contain block content (usually paragraphs)
 			// We need to wrap it properly for ADF
-			content := convertChildren(li, source)
+			content := c.convertChildren(li)
+			if len(content) == 0 {
+				content = []Node{{"type": "paragraph", "content": []Node{}}}
+			}
 			items = append(items, Node{
 				"type":    "listItem",
 				"content": content,
@@ -222,45 +729,96 @@ func convertListItems(list *ast.List, source []byte) []Node {
 // convertInlineChildren recursively processes the inline children of a block
 // node and returns a flat slice of ADF text/inlineCard/hardBreak nodes.
 //
-// The marks parameter carries the accumulated formatting context (bold,
+// The parentMarks parameter carries the accumulated formatting context (bold,
 // italic, code, link, strikethrough) from parent inline nodes and is attached
 // to every leaf text node produced by the traversal. Marks are copied before
 // being extended so that sibling branches do not share slices.
 //
 // Supported inline types:
-//   - [ast.Text]              → "text" (with optional hardBreak / soft-break space)
+//   - [ast.Text]              → "text" (with optional hardBreak / soft break per [WithSoftBreak])
 //   - [ast.Emphasis]          → adds "em" (level 1) or "strong" (level 2) mark
 //   - [ast.CodeSpan]          → "text" with "code" mark
-//   - [ast.Link]              → adds "link" mark with href attr
-//   - [ast.AutoLink]          → "inlineCard" with url attr
-//   - [ast.Image]             → "text" with "link" mark (ADF has no inline image)
+//   - [ast.Link]              → adds "link" mark with href attr (see [WithLinkStyle])
+//   - [ast.AutoLink]          → "inlineCard" with url attr (see [WithLinkStyle])
+//   - [ast.Image]             → "text" with "link" mark (ADF has no inline image),
+//     or "media" with [WithExternalMedia]
 //   - [extast.Strikethrough]  → adds "strike" mark
-//   - [ast.RawHTML]           → skipped
+//   - $math$ spans            → rendered per [WithInlineMath]
+//   - {color:#rrggbb}…{/color} → adds "textColor" mark to the enclosed siblings
+//   - [extast.TaskCheckBox]   → dropped in task lists, literal "[ ] " / "[x] " elsewhere
+//   - [ast.RawHTML]           → skipped (<sup>/<sub> add a "subsup" mark for
+//     the innermost open tag; <kbd> toggles a "code" mark with [WithKbdAsCode])
 //
 // After collecting all nodes the result is passed through [mergeTextNodes] to
 // consolidate adjacent text nodes that share the same marks.
-func convertInlineChildren(n ast.Node, source []byte, marks []Node) []Node {
-	var nodes []Node
+func (c *converter) convertInlineChildren(n ast.Node, parentMarks []Node) []Node {
+	if !c.descend(n) {
+		// Keep the text of overly deep inline nesting without its formatting.
+		text := unescapeText(n.Text(c.source))
+		if text == "" {
+			return nil
+		}
+		return []Node{newTextNode(text, parentMarks)}
+	}
+	defer c.ascend()
+
+	nodes := make([]Node, 0, n.ChildCount())
+	// tagMarks holds marks opened by sibling tag pairs such as <kbd>...</kbd>,
+	// <sup>...</sup>, or {color:...}...{/color}, with at most one mark per
+	// type.
+	var tagMarks []Node
+	// subsups holds the names of the open <sup> and <sub> tags, innermost
+	// last, so that closing a nested tag restores the outer one.
+	var subsups []string
+	// ignoring is set between ignore-start and ignore-end comment directives.
+	var ignoring bool
 
 	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if raw, ok := child.(*ast.RawHTML); ok {
+			if start, end := c.ignoreDirective(c.rawHTMLText(raw)); start || end {
+				ignoring = start
+				continue
+			}
+		}
+		if ignoring {
+			continue
+		}
+		marks := parentMarks
+		if len(tagMarks) > 0 {
+			marks = append(copyMarks(parentMarks), tagMarks...)
+		}
+
 		switch node := child.(type) {
 		case *ast.Text:
-			text := string(node.Segment.Value(source))
-			if text == "" {
-				continue
+			value := node.Segment.Value(c.source)
+			text := string(value)
+			if !node.IsRaw() {
+				// Backslash escapes such as \| in table cells or \* and entity
+				// references such as &amp; are kept in the segment; decode
+				// them like an HTML renderer would.
+				text = unescapeText(value)
 			}
-			textNode := Node{"type": "text", "text": text}
-			if len(marks) > 0 {
-				textNode["marks"] = copyMarks(marks)
+			// goldmark ends a line following closing emphasis or a link with
+			// an empty text node that only carries the line break.
+			text = sanitizeText(text)
+			if c.cfg.stripInvisibleChars {
+				text = stripInvisibleChars(text)
+			}
+			if text != "" {
+				nodes = append(nodes, c.textNodes(text, marks)...)
 			}
-			nodes = append(nodes, textNode)
 
 			// Handle soft/hard line breaks
 			if node.HardLineBreak() {
 				nodes = append(nodes, Node{"type": "hardBreak"})
 			} else if node.SoftLineBreak() {
-				// Soft breaks become spaces in ADF
-				nodes = append(nodes, Node{"type": "text", "text": " "})
+				// Soft breaks become spaces in ADF unless WithSoftBreak says otherwise
+				switch c.cfg.softBreak {
+				case SoftBreakSpace:
+					nodes = append(nodes, Node{"type": "text", "text": " "})
+				case SoftBreakHardBreak:
+					nodes = append(nodes, Node{"type": "hardBreak"})
+				}
 			}
 
 		case *ast.Emphasis:
@@ -269,104 +827,635 @@ func convertInlineChildren(n ast.Node, source []byte, marks []Node) []Node {
 			if node.Level == 2 {
 				markType = "strong"
 			}
-			newMarks := append(copyMarks(marks), Node{"type": markType})
-			nodes = append(nodes, convertInlineChildren(node, source, newMarks)...)
+			newMarks := addMark(marks, Node{"type": markType})
+			nodes = append(nodes, c.convertInlineChildren(node, newMarks)...)
 
 		case *ast.CodeSpan:
-			text := string(node.Text(source))
-			textNode := Node{"type": "text", "text": text}
-			newMarks := append(copyMarks(marks), Node{"type": "code"})
-			textNode["marks"] = newMarks
-			nodes = append(nodes, textNode)
+			// goldmark already strips the fences and one padding space on each
+			// side; CommonMark additionally turns line endings into spaces.
+			text := strings.ReplaceAll(string(node.Text(c.source)), "\n", " ")
+			nodes = append(nodes, newTextNode(text, addMark(marks, Node{"type": "code"})))
 
 		case *ast.Link:
+			href := c.normalizeHref(unescapeText(node.Destination))
+			if c.linkAsCard(href) {
+				nodes = append(nodes, c.inlineCard(href))
+				continue
+			}
 			linkMark := Node{
 				"type":  "link",
-				"attrs": Node{"href": string(node.Destination)},
+				"attrs": Node{"href": href},
 			}
 			newMarks := append(copyMarks(marks), linkMark)
-			nodes = append(nodes, convertInlineChildren(node, source, newMarks)...)
+			nodes = append(nodes, c.convertInlineChildren(node, newMarks)...)
+			c.record(func(s *Stats) { s.Links++ })
 
 		case *ast.AutoLink:
-			url := string(node.URL(source))
+			dest := string(node.URL(c.source))
 			if node.AutoLinkType == ast.AutoLinkEmail {
 				linkMark := Node{
 					"type":  "link",
-					"attrs": Node{"href": "mailto:" + url},
+					"attrs": Node{"href": "mailto:" + dest},
 				}
-				newMarks := append(copyMarks(marks), linkMark)
-				nodes = append(nodes, Node{
-					"type":  "text",
-					"text":  url,
-					"marks": newMarks,
-				})
+				nodes = append(nodes, newTextNode(dest, append(copyMarks(marks), linkMark)))
+				c.record(func(s *Stats) { s.Links++ })
 			} else {
-				nodes = append(nodes, Node{
-					"type":  "inlineCard",
-					"attrs": Node{"url": url},
-				})
+				nodes = append(nodes, c.smartLink(c.normalizeHref(dest), string(node.Label(c.source)), marks))
 			}
 
 		case *ast.Image:
+			c.record(func(s *Stats) { s.Images++ })
+			if c.isMediaImage(node) {
+				// Lifted out of the paragraph by splitMediaParagraph. A
+				// linked image keeps the link as the media's only mark.
+				media := c.mediaNode(node)
+				for _, mark := range marks {
+					if mark["type"] == "link" {
+						media["marks"] = []Node{mark}
+					}
+				}
+				nodes = append(nodes, media)
+				continue
+			}
 			// ADF doesn't support inline images the same way
 			// Convert to a link with the alt text
-			alt := string(node.Text(source))
+			href := c.normalizeHref(unescapeText(node.Destination))
+			alt := string(node.Text(c.source))
 			if alt == "" {
-				alt = string(node.Destination)
+				alt = c.altFallback(href)
+			}
+			if href == "" {
+				// An empty destination, such as a reference defined as "<>",
+				// leaves nothing to link to.
+				nodes = append(nodes, newTextNode(alt, marks))
+				continue
 			}
+			// In a linked image, the outer link's href wins.
 			linkMark := Node{
 				"type":  "link",
-				"attrs": Node{"href": string(node.Destination)},
+				"attrs": Node{"href": href},
 			}
-			newMarks := append(copyMarks(marks), linkMark)
-			textNode := Node{"type": "text", "text": alt, "marks": newMarks}
-			nodes = append(nodes, textNode)
+			nodes = append(nodes, newTextNode(alt, addMark(marks, linkMark)))
+
+		case *inlineMath:
+			math := string(node.Segment.Value(c.source))
+			switch c.cfg.inlineMath {
+			case InlineMathCode:
+				nodes = append(nodes, newTextNode(math, addMark(marks, Node{"type": "code"})))
+			case InlineMathText:
+				nodes = append(nodes, newTextNode(math, marks))
+			}
+
+		case *highlight:
+			color := c.cfg.highlightColor
+			if color == "" {
+				color = defaultHighlightColor
+			}
+			newMarks := append(withoutMark(marks, "backgroundColor"), Node{
+				"type":  "backgroundColor",
+				"attrs": Node{"color": color},
+			})
+			nodes = append(nodes, c.convertInlineChildren(node, newMarks)...)
+
+		case *wikiLink:
+			href, ok := c.cfg.wikiLinks(node.Title)
+			if !ok {
+				nodes = append(nodes, c.textNodes(node.Title, marks)...)
+				continue
+			}
+			href = c.normalizeHref(href)
+			if c.linkAsCard(href) {
+				nodes = append(nodes, c.inlineCard(href))
+				continue
+			}
+			linkMark := Node{
+				"type":  "link",
+				"attrs": Node{"href": href},
+			}
+			nodes = append(nodes, newTextNode(node.Title, addMark(marks, linkMark)))
+			c.record(func(s *Stats) { s.Links++ })
 
 		case *extast.Strikethrough:
-			newMarks := append(copyMarks(marks), Node{"type": "strike"})
-			nodes = append(nodes, convertInlineChildren(node, source, newMarks)...)
+			newMarks := addMark(marks, Node{"type": "strike"})
+			nodes = append(nodes, c.convertInlineChildren(node, newMarks)...)
+
+		case *colorTag:
+			if node.Closing && hasMark(tagMarks, "textColor") {
+				tagMarks = withoutMark(tagMarks, "textColor")
+				continue
+			}
+			if !node.Closing && hasClosingColorTag(node) {
+				tagMarks = append(withoutMark(tagMarks, "textColor"), Node{
+					"type":  "textColor",
+					"attrs": Node{"color": node.Color},
+				})
+				continue
+			}
+			// Unmatched tags are kept as literal text.
+			nodes = append(nodes, newTextNode(string(node.Segment.Value(c.source)), marks))
+
+		case *extast.TaskCheckBox:
+			// In a taskList the checkbox becomes the taskItem state. Otherwise
+			// the list could not be represented as a taskList, so keep the
+			// checkbox as literal text, or a ballot box with WithTaskListAsText.
+			if list, ok := node.Parent().Parent().Parent().(*ast.List); ok && isTaskList(list) && !c.cfg.taskListAsText {
+				continue
+			}
+			box := "[ ] "
+			switch {
+			case c.cfg.taskListAsText && node.IsChecked:
+				box = checkedBoxSymbol + " "
+			case c.cfg.taskListAsText:
+				box = uncheckedBoxSymbol + " "
+			case node.IsChecked:
+				box = "[x] "
+			}
+			nodes = append(nodes, newTextNode(box, marks))
 
 		case *ast.RawHTML:
-			// Skip raw HTML
+			raw := c.rawHTMLText(node)
+			if name, _, ok := parseHTMLTag(raw); ok && name == "br" {
+				nodes = append(nodes, Node{"type": "hardBreak"})
+				continue
+			}
+			// Opening tags without a matching closing tag are skipped below
+			// rather than formatting the rest of the paragraph.
+			if name, closing, ok := parseHTMLTag(raw); ok && name == "kbd" && c.cfg.kbdAsCode && (closing || c.hasClosingHTMLTag(node, name)) {
+				tagMarks = withoutMark(tagMarks, "code")
+				if !closing {
+					tagMarks = append(tagMarks, Node{"type": "code"})
+				}
+				continue
+			}
+			if name, closing, ok := parseHTMLTag(raw); ok && (name == "sup" || name == "sub") && (closing || c.hasClosingHTMLTag(node, name)) {
+				if closing {
+					for i := len(subsups) - 1; i >= 0; i-- {
+						if subsups[i] == name {
+							subsups = slices.Delete(subsups, i, i+1)
+							break
+						}
+					}
+				} else {
+					subsups = append(subsups, name)
+				}
+				tagMarks = withoutMark(tagMarks, "subsup")
+				if len(subsups) > 0 {
+					tagMarks = append(tagMarks, Node{"type": "subsup", "attrs": Node{"type": subsups[len(subsups)-1]}})
+				}
+				continue
+			}
+			// Any other raw HTML is skipped, comments silently
+			if _, ok := htmlComment(raw); !ok {
+				c.warn(node, "inline raw HTML %q skipped", raw)
+				c.record(func(s *Stats) { s.DroppedHTML++ })
+			}
 			continue
 
 		default:
 			// For other inline nodes, try to recurse
 			if child.HasChildren() {
-				nodes = append(nodes, convertInlineChildren(child, source, marks)...)
+				nodes = append(nodes, c.convertInlineChildren(child, marks)...)
 			}
 		}
 	}
 
+	nodes = mergeTextNodes(nodes)
+	if c.cfg.collapseWhitespace || c.cfg.inlineCheckboxes {
+		// goldmark splits text around inline nodes and brackets, so runs
+		// of spaces and checkboxes are only complete once adjacent text
+		// nodes have been merged.
+		for _, node := range nodes {
+			if marks, _ := node["marks"].([]Node); node["type"] == "text" && !hasMark(marks, "code") {
+				node["text"] = c.rewriteText(node["text"].(string))
+			}
+		}
+	}
+	return nodes
+}
+
+// rewriteText applies the text rewrites enabled by [WithCollapseWhitespace]
+// and [WithInlineCheckboxes] to merged text outside code.
+func (c *converter) rewriteText(text string) string {
+	if c.cfg.collapseWhitespace {
+		text = collapseWhitespace(text)
+	}
+	if c.cfg.inlineCheckboxes {
+		text = replaceInlineCheckboxes(text)
+	}
+	return text
+}
+
+// textNodes converts a run of plain source text carrying the given marks into
+// ADF inline nodes. Normally this is a single "text" node, but text-level
+// options such as [WithIssueKeyLinking] and [WithUnicodeEmoji] may split the
+// run around the recognized tokens.
+func (c *converter) textNodes(text string, marks []Node) []Node {
+	if c.cfg.issueBaseURL != "" && !hasMark(marks, "link") {
+		return c.linkIssueKeys(text, marks)
+	}
+	return c.emojiNodes(text, marks)
+}
+
+// smartLink renders a bare URL or issue key shown as text. By default it
+// becomes an "inlineCard"; with [LinkStyleText], or for URLs that cannot be
+// resolved as a smart link such as "tel:" links or hosts not listed by
+// [WithSmartLinkHosts], it is a text node carrying a link mark along with
+// the surrounding marks, which inlineCard nodes cannot hold.
+func (c *converter) smartLink(url, text string, marks []Node) Node {
+	if c.cfg.linkStyle == LinkStyleText || !c.isCardURL(url) {
+		c.record(func(s *Stats) { s.Links++ })
+		return newTextNode(text, addMark(marks, Node{"type": "link", "attrs": Node{"href": url}}))
+	}
+	return c.inlineCard(url)
+}
+
+// inlineCard returns an "inlineCard" smart link to url.
+func (c *converter) inlineCard(url string) Node {
+	c.record(func(s *Stats) { s.Cards++ })
+	return Node{"type": "inlineCard", "attrs": Node{"url": url}}
+}
+
+// linkAsCard reports whether an explicit link to href is rendered as an
+// "inlineCard" under [LinkStyleCard]. Only absolute http and https URLs
+// qualify, since cards for fragments, relative paths, or mailto links
+// cannot be resolved.
+func (c *converter) linkAsCard(href string) bool {
+	return c.cfg.linkStyle == LinkStyleCard && c.isCardURL(href)
+}
+
+// isCardURL reports whether href is an absolute http or https URL, the only
+// kind of URL a smart link card can resolve, on one of the hosts given by
+// [WithSmartLinkHosts], if any.
+func (c *converter) isCardURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	return c.cfg.smartLinkHosts == nil || matchHost(u.Hostname(), c.cfg.smartLinkHosts)
+}
+
+// newTextNode returns a "text" node holding text cleaned by [sanitizeText]
+// with a private copy of marks attached, reduced to one mark per type by
+// [dedupeMarks] and sorted into canonical order by [sortMarks]. The "marks"
+// key is omitted entirely when marks is empty.
+func newTextNode(text string, marks []Node) Node {
+	textNode := Node{"type": "text", "text": sanitizeText(text)}
+	if len(marks) > 0 {
+		textNode["marks"] = sortMarks(dedupeMarks(marks))
+	}
+	return textNode
+}
+
+// dedupeMarks returns a copy of marks keeping only the first, outermost mark
+// of each type, as ADF rejects text nodes carrying two marks of one type.
+// [addMark] already avoids duplicates; this also covers marks appended
+// directly, such as link marks and the marks of tag pairs.
+func dedupeMarks(marks []Node) []Node {
+	result := make([]Node, 0, len(marks))
+	for _, mark := range marks {
+		if markType, _ := mark["type"].(string); !hasMark(result, markType) {
+			result = append(result, mark)
+		}
+	}
+	return result
+}
+
+// markOrder ranks mark types for [sortMarks]. Unlisted types sort last.
+var markOrder = map[string]int{
+	"link":            1,
+	"code":            2,
+	"strong":          3,
+	"em":              4,
+	"strike":          5,
+	"underline":       6,
+	"subsup":          7,
+	"textColor":       8,
+	"backgroundColor": 9,
+}
+
+// sortMarks sorts marks in place into a canonical order (link, code,
+// strong, em, strike, then the remaining types), independent of the order
+// in which the Markdown nested them, so that equal formatting always
+// marshals identically. Marks of unlisted types keep their relative order.
+func sortMarks(marks []Node) []Node {
+	rank := func(mark Node) int {
+		markType, _ := mark["type"].(string)
+		if r, ok := markOrder[markType]; ok {
+			return r
+		}
+		return len(markOrder) + 1
+	}
+	slices.SortStableFunc(marks, func(a, b Node) int {
+		return rank(a) - rank(b)
+	})
+	return marks
+}
+
+// issueKeyPattern matches Jira issue keys such as "DEV-123".
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z]+-\d+\b`)
+
+// linkIssueKeys splits text around Jira issue keys and turns every key into
+// an "inlineCard" pointing at the configured issue base URL, or a text link
+// with [LinkStyleText]. The surrounding text keeps its marks; the cards
+// themselves carry none, as ADF does not allow marks on inlineCard nodes.
+func (c *converter) linkIssueKeys(text string, marks []Node) []Node {
+	var nodes []Node
+	last := 0
+	for _, loc := range issueKeyPattern.FindAllStringIndex(text, -1) {
+		if loc[0] > last {
+			nodes = append(nodes, c.emojiNodes(text[last:loc[0]], marks)...)
+		}
+		key := text[loc[0]:loc[1]]
+		nodes = append(nodes, c.smartLink(c.cfg.issueBaseURL+key, key, marks))
+		last = loc[1]
+	}
+	if last < len(text) {
+		nodes = append(nodes, c.emojiNodes(text[last:], marks)...)
+	}
+	return nodes
+}
+
+// isBlankParagraph reports whether a paragraph with the given content holds
+// only unformatted whitespace and line breaks, so it is dropped like an empty
+// one. With [WithNbspSpacers], paragraphs holding a non-breaking space are
+// kept as spacers. Whitespace in code spans is content and is kept.
+func (c *converter) isBlankParagraph(content []Node) bool {
+	if !isBlankInline(content) {
+		return false
+	}
+	for _, node := range content {
+		if _, ok := node["marks"]; ok {
+			return false
+		}
+	}
+	return !c.cfg.nbspSpacers || !strings.ContainsRune(plainText(content), '\u00a0')
+}
+
+// breaksToSpaces replaces every "hardBreak" in the given inline nodes with a
+// single space and merges the resulting adjacent text runs.
+func breaksToSpaces(nodes []Node) []Node {
+	for i, node := range nodes {
+		if node["type"] == "hardBreak" {
+			nodes[i] = Node{"type": "text", "text": " "}
+		}
+	}
+	return mergeTextNodes(nodes)
+}
+
+// stripFormattingMarks removes every mark except "link" from the given inline
+// nodes and re-merges text runs that become identical as a result.
+func stripFormattingMarks(nodes []Node) []Node {
+	for _, node := range nodes {
+		marks, ok := node["marks"].([]Node)
+		if !ok {
+			continue
+		}
+		var kept []Node
+		for _, m := range marks {
+			if m["type"] == "link" {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == 0 {
+			delete(node, "marks")
+		} else {
+			node["marks"] = kept
+		}
+	}
 	return mergeTextNodes(nodes)
 }
 
+// withoutMark returns marks without any mark of the given type. The input
+// slice is not modified.
+func withoutMark(marks []Node, markType string) []Node {
+	var result []Node
+	for _, m := range marks {
+		if m["type"] != markType {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// hasMark reports whether marks contains a mark of the given type.
+func hasMark(marks []Node, markType string) bool {
+	for _, m := range marks {
+		if m["type"] == markType {
+			return true
+		}
+	}
+	return false
+}
+
+// entityPattern matches an HTML entity or numeric character reference.
+var entityPattern = regexp.MustCompile(`^&(?:#[xX]([0-9a-fA-F]{1,6})|#([0-9]{1,7})|([A-Za-z][A-Za-z0-9]{1,31}));`)
+
+// unescapeText decodes the backslash escapes and entity references that
+// goldmark leaves in text segments and link destinations. Both are decoded
+// in a single pass, so an escaped "\&amp;" stays the literal "&amp;".
+// Unknown entities are kept as written, and invalid numeric references
+// become U+FFFD as CommonMark requires.
+func unescapeText(src []byte) string {
+	if bytes.IndexByte(src, '\\') < 0 && bytes.IndexByte(src, '&') < 0 {
+		return string(src)
+	}
+	var sb strings.Builder
+	for i := 0; i < len(src); i++ {
+		switch {
+		case src[i] == '\\' && i+1 < len(src) && util.IsPunct(src[i+1]):
+			i++
+			sb.WriteByte(src[i])
+			continue
+		case src[i] == '&':
+			if m := entityPattern.FindSubmatch(src[i:]); m != nil {
+				if decoded, ok := decodeEntity(m); ok {
+					sb.WriteString(decoded)
+					i += len(m[0]) - 1
+					continue
+				}
+			}
+		}
+		sb.WriteByte(src[i])
+	}
+	return sb.String()
+}
+
+// sanitizeText removes the characters that ADF consumers such as Jira
+// reject from s: control characters other than tab and newline, including
+// carriage returns and NUL, and the noncharacters U+FFFE and U+FFFF. Invalid
+// UTF-8 sequences are replaced by U+FFFD.
+func sanitizeText(s string) string {
+	if utf8.ValidString(s) && !strings.ContainsFunc(s, isUnsafeRune) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if isUnsafeRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// stripInvisibleChars removes the invisible characters dropped by
+// [WithStripInvisibleChars] from s.
+func stripInvisibleChars(s string) string {
+	if !strings.ContainsFunc(s, isInvisibleRune) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if isInvisibleRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// whitespacePattern matches a run of spaces and tabs.
+var whitespacePattern = regexp.MustCompile(`[ \t]{2,}|\t`)
+
+// collapseWhitespace replaces every run of spaces and tabs in s with a
+// single space.
+func collapseWhitespace(s string) string {
+	if !strings.Contains(s, "  ") && !strings.Contains(s, "\t") {
+		return s
+	}
+	return whitespacePattern.ReplaceAllString(s, " ")
+}
+
+// Symbols replacing checkboxes with [WithInlineCheckboxes] and
+// [WithTaskListAsText].
+const (
+	uncheckedBoxSymbol = "\u2610"
+	checkedBoxSymbol   = "\u2611"
+)
+
+// replaceInlineCheckboxes replaces "[ ]" and "[x]" in s with ballot box
+// symbols. A checkbox must stand alone: it follows the start of s, a space,
+// or an opening parenthesis and is followed by the end of s, a space, or
+// punctuation, so that brackets such as "a[x]" or "[x]y" stay literal.
+func replaceInlineCheckboxes(s string) string {
+	if !strings.Contains(s, "[ ]") && !strings.Contains(s, "[x]") && !strings.Contains(s, "[X]") {
+		return s
+	}
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '[' && i+2 < len(s) && s[i+2] == ']' &&
+			(i == 0 || strings.IndexByte(" \t(", s[i-1]) >= 0) &&
+			(i+3 == len(s) || strings.IndexByte(" \t.,;:!?)", s[i+3]) >= 0) {
+			switch s[i+1] {
+			case ' ':
+				buf.WriteString(uncheckedBoxSymbol)
+				i += 2
+				continue
+			case 'x', 'X':
+				buf.WriteString(checkedBoxSymbol)
+				i += 2
+				continue
+			}
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+// isInvisibleRune reports whether r is removed by [stripInvisibleChars].
+func isInvisibleRune(r rune) bool {
+	return r == '\u00ad' || r == '\u200b' || r == '\u2060' || r == '\ufeff'
+}
+
+// isUnsafeRune reports whether r is removed by [sanitizeText].
+func isUnsafeRune(r rune) bool {
+	return (r < 0x20 && r != '\t' && r != '\n') || (r >= 0x7f && r <= 0x9f) || r == 0xfffe || r == 0xffff
+}
+
+// decodeEntity resolves an [entityPattern] match to its characters. It
+// reports false for unknown entity names.
+func decodeEntity(m [][]byte) (string, bool) {
+	if len(m[3]) > 0 {
+		entity, ok := util.LookUpHTML5EntityByName(string(m[3]))
+		if !ok {
+			return "", false
+		}
+		return string(entity.Characters), true
+	}
+	base, digits := 16, m[1]
+	if len(digits) == 0 {
+		base, digits = 10, m[2]
+	}
+	code, _ := strconv.ParseInt(string(digits), base, 32)
+	if code == 0 || code > utf8.MaxRune || (code >= 0xd800 && code <= 0xdfff) {
+		return string(utf8.RuneError), true
+	}
+	return string(rune(code)), true
+}
+
+// normalizeHref cleans up a link destination before it is written to ADF.
+// Surrounding whitespace and wrapping angle brackets are removed, and when a
+// base URL was configured via [WithBaseURL], relative references are resolved
+// against it. Destinations that fail to parse are returned trimmed but
+// otherwise unchanged.
+func (c *converter) normalizeHref(href string) string {
+	href = sanitizeText(strings.TrimSpace(href))
+	if len(href) >= 2 && href[0] == '<' && href[len(href)-1] == '>' {
+		href = strings.TrimSpace(href[1 : len(href)-1])
+	}
+	if c.cfg.baseURL == nil || href == "" {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil || ref.IsAbs() {
+		return href
+	}
+	return c.cfg.baseURL.ResolveReference(ref).String()
+}
+
 // mergeTextNodes consolidates adjacent "text" nodes that share identical marks
 // by concatenating their text values. This is necessary because goldmark
 // extensions (e.g. Linkify) can split what is logically one text run at
 // internal probe points, producing fragmented nodes that would result in
-// unnecessarily verbose ADF output.
+// unnecessarily verbose ADF output. Text nodes with empty text, which ADF
+// rejects, are dropped. The text of a run is joined once, so that long runs
+// of fragments merge in linear time.
 func mergeTextNodes(nodes []Node) []Node {
-	if len(nodes) <= 1 {
-		return nodes
-	}
-	merged := []Node{nodes[0]}
-	for _, node := range nodes[1:] {
-		prev := merged[len(merged)-1]
-		if prev["type"] == "text" && node["type"] == "text" && marksEqual(prev, node) {
-			prev["text"] = prev["text"].(string) + node["text"].(string)
+	merged := make([]Node, 0, len(nodes))
+	// run collects the text of the last merged node while further nodes
+	// are merged into it.
+	var run strings.Builder
+	running := false
+	flush := func() {
+		if running {
+			merged[len(merged)-1]["text"] = run.String()
+			running = false
+		}
+	}
+	for _, node := range nodes {
+		if node["type"] == "text" && node["text"] == "" {
 			continue
 		}
+		if len(merged) > 0 {
+			prev := merged[len(merged)-1]
+			if prev["type"] == "text" && node["type"] == "text" && marksEqual(prev, node) {
+				if !running {
+					run.Reset()
+					run.WriteString(prev["text"].(string))
+					running = true
+				}
+				run.WriteString(node["text"].(string))
+				continue
+			}
+		}
+		flush()
 		merged = append(merged, node)
 	}
+	flush()
 	return merged
 }
 
 // marksEqual reports whether two text nodes carry the same set of marks.
 // Two nodes are considered equal if they both have no marks, or if their mark
-// slices are the same length and each pair of marks has identical string
-// representations. This is used by [mergeTextNodes] to decide whether
-// adjacent text nodes can be combined.
+// slices are the same length and each pair of marks holds equal values. This
+// is used by [mergeTextNodes] to decide whether adjacent text nodes can be
+// combined.
 func marksEqual(a, b Node) bool {
 	aMarks, aOk := a["marks"].([]Node)
 	bMarks, bOk := b["marks"].([]Node)
@@ -377,62 +1466,158 @@ func marksEqual(a, b Node) bool {
 		return false
 	}
 	for i := range aMarks {
-		if fmt.Sprint(aMarks[i]) != fmt.Sprint(bMarks[i]) {
+		if !valuesEqual(aMarks[i], bMarks[i]) {
 			return false
 		}
 	}
 	return true
 }
 
+// valuesEqual compares two mark values field by field. Marks and their attrs
+// only hold nested nodes and scalars, which are compared directly; any other
+// type falls back to comparing string representations.
+func valuesEqual(a, b any) bool {
+	switch av := a.(type) {
+	case Node:
+		bv, ok := b.(Node)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !valuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case string, bool, int, float64:
+		return a == b
+	default:
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+}
+
 // convertTable converts a goldmark [extast.Table] into an ADF "table" node.
 //
-// The resulting table has "isNumberColumnEnabled" set to false and layout
-// "default". The first child (TableHeader) produces cells of type
-// "tableHeader"; subsequent TableRow children produce "tableCell" nodes.
-func convertTable(table *extast.Table, source []byte) Node {
-	var rows []Node
+// The table's attrs come from [converter.tableAttrs]: "layout" is set by
+// [WithTableLayout] and "isNumberColumnEnabled" by [WithTableNumberColumn],
+// defaulting to "default" and false. With [WithTablesAsList], the table is
+// converted by [converter.convertTableAsList] instead. The first child
+// (TableHeader) produces cells of type "tableHeader"; subsequent TableRow
+// children produce "tableCell" nodes. Rows shorter than the header are
+// padded with empty cells, and rows without any cells are omitted.
+func (c *converter) convertTable(table *extast.Table) Node {
+	if c.cfg.tablesAsList {
+		return c.convertTableAsList(table)
+	}
+	rows := make([]Node, 0, table.ChildCount())
+	width := 0
 	for child := table.FirstChild(); child != nil; child = child.NextSibling() {
+		var cells []Node
+		cellType := "tableCell"
 		switch row := child.(type) {
 		case *extast.TableHeader:
-			rows = append(rows, Node{
-				"type":    "tableRow",
-				"content": convertTableCells(row, source, "tableHeader"),
-			})
+			cellType = "tableHeader"
+			cells = c.convertTableCells(row, cellType)
+			width = max(width, len(cells))
 		case *extast.TableRow:
-			rows = append(rows, Node{
-				"type":    "tableRow",
-				"content": convertTableCells(row, source, "tableCell"),
-			})
+			cells = c.convertTableCells(row, cellType)
+		default:
+			continue
 		}
+		// ADF rejects rows without cells, and ragged rows render badly, so
+		// pad every row to the header width with empty cells.
+		for len(cells) < width {
+			cells = append(cells, emptyTableCell(cellType))
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		rows = append(rows, Node{
+			"type":    "tableRow",
+			"content": cells,
+		})
 	}
 	return Node{
 		"type":    "table",
-		"attrs":   Node{"isNumberColumnEnabled": false, "layout": "default"},
+		"attrs":   c.tableAttrs(),
 		"content": rows,
 	}
 }
 
+// convertTableAsList converts a goldmark [extast.Table] into an ADF
+// "bulletList" for [WithTablesAsList]. Each data row becomes a list item
+// holding one paragraph per non-empty cell, labelled with the plain text of
+// the matching header cell in bold, as in "Name: Alice". Rows without any
+// content are omitted. A table whose data rows are all empty becomes a
+// single item holding the header labels in bold, and one without any
+// content is dropped with a diagnostic.
+func (c *converter) convertTableAsList(table *extast.Table) Node {
+	var labels []string
+	var items []Node
+	for child := table.FirstChild(); child != nil; child = child.NextSibling() {
+		if header, ok := child.(*extast.TableHeader); ok {
+			for cell := header.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				label := strings.TrimSpace(plainText(c.convertInlineChildren(cell, nil)))
+				labels = append(labels, label)
+			}
+			continue
+		}
+		row, ok := child.(*extast.TableRow)
+		if !ok {
+			continue
+		}
+		var pairs []Node
+		column := 0
+		for cell := row.FirstChild(); cell != nil; cell, column = cell.NextSibling(), column+1 {
+			value := c.convertInlineChildren(cell, nil)
+			if len(value) == 0 {
+				continue
+			}
+			if column < len(labels) && labels[column] != "" {
+				label := newTextNode(labels[column]+":", []Node{{"type": "strong"}})
+				value = mergeTextNodes(append([]Node{label, newTextNode(" ", nil)}, value...))
+			}
+			pairs = append(pairs, Node{"type": "paragraph", "content": value})
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		c.recordBlocks(pairs)
+		items = append(items, Node{"type": "listItem", "content": pairs})
+	}
+	if len(items) == 0 {
+		var headers []Node
+		for _, label := range labels {
+			if label != "" {
+				headers = append(headers, Node{"type": "paragraph", "content": []Node{newTextNode(label, []Node{{"type": "strong"}})}})
+			}
+		}
+		if len(headers) == 0 {
+			c.warn(table, "table without content dropped")
+			return nil
+		}
+		c.recordBlocks(headers)
+		items = append(items, Node{"type": "listItem", "content": headers})
+	}
+	return Node{"type": "bulletList", "content": items}
+}
+
 // convertTableCells converts the [extast.TableCell] children of a table row
 // into ADF nodes of the given cellType ("tableHeader" or "tableCell"). Each
-// cell's inline content is wrapped in a paragraph node, as required by the
-// ADF schema. Empty cells receive a paragraph with an empty content array.
-func convertTableCells(row ast.Node, source []byte, cellType string) []Node {
-	var cells []Node
+// cell's inline content is arranged into blocks by [cellBlocks], as the ADF
+// schema requires. Empty cells receive a paragraph with an empty content
+// array.
+func (c *converter) convertTableCells(row ast.Node, cellType string) []Node {
+	cells := make([]Node, 0, row.ChildCount())
 	for child := row.FirstChild(); child != nil; child = child.NextSibling() {
 		if _, ok := child.(*extast.TableCell); ok {
-			inlineContent := convertInlineChildren(child, source, nil)
-			var content []Node
-			if len(inlineContent) > 0 {
-				content = []Node{{
-					"type":    "paragraph",
-					"content": inlineContent,
-				}}
-			} else {
-				content = []Node{{
-					"type":    "paragraph",
-					"content": []Node{},
-				}}
+			content := cellBlocks(c.convertInlineChildren(child, nil))
+			if len(content) == 0 {
+				cells = append(cells, emptyTableCell(cellType))
+				continue
 			}
+			c.recordBlocks(content)
 			cells = append(cells, Node{
 				"type":    cellType,
 				"content": content,
@@ -442,6 +1627,119 @@ func convertTableCells(row ast.Node, source []byte, cellType string) []Node {
 	return cells
 }
 
+// cellBulletPrefix starts a line that [cellBlocks] turns into a list item.
+const cellBulletPrefix = "• "
+
+// cellBlocks arranges the inline content of a GFM table cell into blocks.
+// GFM cells hold a single line, so richer ADF cell content follows a
+// convention: <br> is a line break, an empty line such as <br><br> starts a
+// new paragraph, and lines starting with "• " become items of a bullet
+// list. It returns nil if the cell holds no content.
+func cellBlocks(inline []Node) []Node {
+	lines := [][]Node{make([]Node, 0, len(inline))}
+	for _, node := range inline {
+		if node["type"] == "hardBreak" {
+			lines = append(lines, nil)
+			continue
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], node)
+	}
+
+	var blocks, paragraph, items []Node
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, Node{"type": "paragraph", "content": paragraph})
+			paragraph = nil
+		}
+		if len(items) > 0 {
+			blocks = append(blocks, Node{"type": "bulletList", "content": items})
+			items = nil
+		}
+	}
+	for _, line := range lines {
+		line = trimInline(line)
+		if len(line) == 0 {
+			flush()
+			continue
+		}
+		if text, ok := line[0]["text"].(string); ok && strings.HasPrefix(text, cellBulletPrefix) {
+			if len(paragraph) > 0 {
+				flush()
+			}
+			marks, _ := line[0]["marks"].([]Node)
+			item := append([]Node{newTextNode(text[len(cellBulletPrefix):], marks)}, line[1:]...)
+			item = trimInline(item)
+			items = append(items, Node{
+				"type":    "listItem",
+				"content": []Node{{"type": "paragraph", "content": item}},
+			})
+			continue
+		}
+		if len(items) > 0 {
+			flush()
+		}
+		if len(paragraph) > 0 {
+			paragraph = append(paragraph, Node{"type": "hardBreak"})
+		}
+		paragraph = append(paragraph, line...)
+	}
+	flush()
+	return blocks
+}
+
+// emptyTableCell returns a cell of the given cellType holding a single empty
+// paragraph, the minimal content the ADF schema accepts for a cell.
+func emptyTableCell(cellType string) Node {
+	return Node{
+		"type": cellType,
+		"content": []Node{{
+			"type":    "paragraph",
+			"content": []Node{},
+		}},
+	}
+}
+
+// tableAttrs returns the attrs shared by every converted table: the
+// "layout" from [WithTableLayout], "default" unless set, and
+// "isNumberColumnEnabled" from [WithTableNumberColumn].
+func (c *converter) tableAttrs() Node {
+	layout := c.cfg.tableLayout
+	if layout == "" {
+		layout = "default"
+	}
+	return Node{"isNumberColumnEnabled": c.cfg.tableNumberColumn, "layout": layout}
+}
+
+// localID returns a localId attr value for ADF nodes that need one, using
+// the [WithLocalIDGenerator] generator if configured.
+func (c *converter) localID() string {
+	if c.cfg.localIDGenerator != nil {
+		return c.cfg.localIDGenerator()
+	}
+	return newLocalID()
+}
+
+// newLocalID returns a random RFC 4122 version 4 UUID for ADF nodes that
+// require a "localId" attr, such as "taskList" and "taskItem".
+func newLocalID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// addMark returns a copy of marks with mark appended. If marks already holds
+// a mark of the same type it is returned unchanged, so nested identical
+// formatting such as "**a **b** c**" does not produce duplicate marks, which
+// ADF rejects.
+func addMark(marks []Node, mark Node) []Node {
+	if hasMark(marks, mark["type"].(string)) {
+		return copyMarks(marks)
+	}
+	return append(copyMarks(marks), mark)
+}
+
 // copyMarks returns a shallow copy of the marks slice so that callers can
 // safely append to it without mutating the slice shared by sibling inline
 // nodes. A nil input produces a nil result.
@@ -2,7 +2,11 @@ package md2adf
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestConvert_Paragraph(t *testing.T) {
@@ -53,6 +57,135 @@ func TestConvert_Heading(t *testing.T) {
 	}
 }
 
+func TestConvert_HeadingOffset(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+		level  int
+	}{
+		{"# Title", 1, 2},
+		{"## Title", 2, 4},
+		{"###### Title", 1, 6},
+		{"### Title", -1, 2},
+		{"# Title", -3, 1},
+		{"## Title", 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.input, tt.offset), func(t *testing.T) {
+			result := Convert(tt.input, WithHeadingOffset(tt.offset))
+			heading := result["content"].([]Node)[0]
+
+			assertType(t, heading, "heading")
+			attrs := heading["attrs"].(Node)
+			if attrs["level"] != tt.level {
+				t.Errorf("expected level %d, got %v", tt.level, attrs["level"])
+			}
+		})
+	}
+}
+
+func TestConvert_SetextHeading(t *testing.T) {
+	tests := []struct {
+		input string
+		level int
+		text  string
+	}{
+		{"Title\n===", 1, "Title"},
+		{"Subtitle\n---", 2, "Subtitle"},
+		{"Intro\n\nSubtitle\n--------", 2, "Subtitle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := Convert(tt.input)
+			content := result["content"].([]Node)
+			heading := content[len(content)-1]
+
+			assertType(t, heading, "heading")
+			if attrs := heading["attrs"].(Node); attrs["level"] != tt.level {
+				t.Errorf("expected level %d, got %v", tt.level, attrs["level"])
+			}
+			assertText(t, heading["content"].([]Node)[0], tt.text)
+			if rules := collectNodes(result, "rule"); len(rules) != 0 {
+				t.Errorf("expected no rule nodes, got %d", len(rules))
+			}
+		})
+	}
+}
+
+func TestConvert_HeadingInlineMarks(t *testing.T) {
+	const input = "# See **bold** [docs](https://example.com) and `code`"
+
+	t.Run("default keeps marks", func(t *testing.T) {
+		content := Convert(input)["content"].([]Node)[0]["content"].([]Node)
+		texts := []string{"See ", "bold", " ", "docs", " and ", "code"}
+		marks := [][]string{nil, {"strong"}, nil, {"link"}, nil, {"code"}}
+		if len(content) != len(texts) {
+			t.Fatalf("expected %d nodes, got %d", len(texts), len(content))
+		}
+		for i, node := range content {
+			assertText(t, node, texts[i])
+			assertMarks(t, node, marks[i]...)
+		}
+	})
+
+	t.Run("plain headings", func(t *testing.T) {
+		content := Convert(input, WithPlainHeadings())["content"].([]Node)[0]["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(content))
+		}
+		assertText(t, content[0], "See bold ")
+		assertMarks(t, content[0])
+		assertText(t, content[1], "docs")
+		assertMarks(t, content[1], "link")
+		assertText(t, content[2], " and code")
+		assertMarks(t, content[2])
+	})
+}
+
+func TestConvert_HeadingBreaksBecomeSpaces(t *testing.T) {
+	inputs := map[string][]Option{
+		"Line one  \nLine two\n===": nil,
+		"Line one\\\nLine two\n===": nil,
+		"Line one\nLine two\n===":   {WithSoftBreak(SoftBreakHardBreak)},
+	}
+
+	for input, opts := range inputs {
+		t.Run(input, func(t *testing.T) {
+			heading := Convert(input, opts...)["content"].([]Node)[0]
+			assertType(t, heading, "heading")
+
+			content := heading["content"].([]Node)
+			if len(content) != 1 {
+				t.Fatalf("expected 1 text node, got %d: %v", len(content), content)
+			}
+			assertText(t, content[0], "Line one Line two")
+		})
+	}
+}
+
+func TestConvert_EmptyHeading(t *testing.T) {
+	for _, input := range []string{"#", "##   ", "###### #"} {
+		t.Run(input, func(t *testing.T) {
+			content := Convert("Above\n\n" + input + "\n\nBelow")["content"].([]Node)
+			if len(content) != 2 {
+				t.Fatalf("expected the empty heading to be dropped, got %d nodes: %v", len(content), content)
+			}
+			assertText(t, content[0]["content"].([]Node)[0], "Above")
+			assertText(t, content[1]["content"].([]Node)[0], "Below")
+		})
+	}
+
+	t.Run("diagnostic", func(t *testing.T) {
+		_, diags := ConvertWithDiagnostics("Text\n\n##")
+		want := Diagnostic{Message: "empty heading dropped", Line: 3, Column: 1}
+		if len(diags) != 1 || diags[0] != want {
+			t.Errorf("expected %v, got %v", want, diags)
+		}
+	})
+}
+
 func TestConvert_BulletList(t *testing.T) {
 	input := `- Item 1
 - Item 2
@@ -94,6 +227,64 @@ func TestConvert_OrderedList(t *testing.T) {
 	}
 }
 
+func TestConvert_OrderedListInterrupted(t *testing.T) {
+	content := Convert("1. one\n2. two\n\nA paragraph.\n\n5. five\n6. six")["content"].([]Node)
+	if len(content) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(content))
+	}
+
+	assertType(t, content[0], "orderedList")
+	if _, ok := content[0]["attrs"]; ok {
+		t.Errorf("expected no attrs on a list starting at 1, got %v", content[0]["attrs"])
+	}
+	assertType(t, content[1], "paragraph")
+	assertType(t, content[2], "orderedList")
+	if order := content[2]["attrs"].(Node)["order"]; order != 5 {
+		t.Errorf("expected order 5, got %v", order)
+	}
+	if items := content[2]["content"].([]Node); len(items) != 2 {
+		t.Errorf("expected 2 items, got %d", len(items))
+	}
+}
+
+func TestConvert_OrderedListStart(t *testing.T) {
+	tests := []struct {
+		input string
+		order any // nil means no attrs
+	}{
+		// Later markers are ignored; only the first sets the start.
+		{"999. a\n1. b\n7. c", 999},
+		// CommonMark allows at most nine digits, so the start fits an int.
+		{"999999999. a", 999999999},
+		{"0. a\n1. b", 0},
+		{"007. a", 7},
+		{"1. a\n5. b", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			list := Convert(tt.input)["content"].([]Node)[0]
+			assertType(t, list, "orderedList")
+			attrs, ok := list["attrs"].(Node)
+			if tt.order == nil {
+				if ok {
+					t.Errorf("expected no attrs, got %v", attrs)
+				}
+				return
+			}
+			if !ok || attrs["order"] != tt.order {
+				t.Errorf("expected order %v, got %v", tt.order, list["attrs"])
+			}
+		})
+	}
+
+	// Neither negative nor ten-digit markers start a list.
+	for _, input := range []string{"-1. a", "1234567890. a"} {
+		content := Convert(input)["content"].([]Node)
+		assertType(t, content[0], "paragraph")
+		assertText(t, content[0]["content"].([]Node)[0], input)
+	}
+}
+
 func TestConvert_CodeBlock(t *testing.T) {
 	input := "```go\nfunc main() {\n\tfmt.Println(\"Hello\")\n}\n```"
 
@@ -114,6 +305,64 @@ func TestConvert_CodeBlock(t *testing.T) {
 	}
 }
 
+func TestConvert_CodeBlockInfoString(t *testing.T) {
+	tests := []struct {
+		info string
+		want any
+	}{
+		{"go", "go"},
+		{"go title=\"main.go\"", "go"},
+		{"  python   filename=app.py  linenos", "python"},
+		{"title=\"main.go\"", nil},
+		{"c\\+\\+", "c++"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.info, func(t *testing.T) {
+			block := Convert("```" + tt.info + "\nx\n```")["content"].([]Node)[0]
+			assertType(t, block, "codeBlock")
+			var got any
+			if attrs, ok := block["attrs"].(Node); ok {
+				got = attrs["language"]
+			}
+			if got != tt.want {
+				t.Errorf("expected language %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConvert_SoftBreakModes(t *testing.T) {
+	const input = "221B Baker Street\nLondon"
+
+	t.Run("space", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithSoftBreak(SoftBreakSpace)}} {
+			paraContent := Convert(input, opts...)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 1 {
+				t.Fatalf("expected 1 node, got %d", len(paraContent))
+			}
+			assertText(t, paraContent[0], "221B Baker Street London")
+		}
+	})
+
+	t.Run("hardBreak", func(t *testing.T) {
+		paraContent := Convert(input, WithSoftBreak(SoftBreakHardBreak))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "221B Baker Street")
+		assertType(t, paraContent[1], "hardBreak")
+		assertText(t, paraContent[2], "London")
+	})
+
+	t.Run("join", func(t *testing.T) {
+		paraContent := Convert(input, WithSoftBreak(SoftBreakJoin))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "221B Baker StreetLondon")
+	})
+}
+
 func TestConvert_Bold(t *testing.T) {
 	result := Convert("This is **bold** text")
 	content := result["content"].([]Node)
@@ -157,6 +406,32 @@ func TestConvert_Italic(t *testing.T) {
 	}
 }
 
+func TestConvert_AdjacentMarksNoLeakage(t *testing.T) {
+	tests := []struct {
+		input string
+		texts []string
+		marks [][]string
+	}{
+		{"**bold**_italic_", []string{"bold", "italic"}, [][]string{{"strong"}, {"em"}}},
+		{"**a**b", []string{"a", "b"}, [][]string{{"strong"}, nil}},
+		{"a*b*c", []string{"a", "b", "c"}, [][]string{nil, {"em"}, nil}},
+		{"~~x~~**y**", []string{"x", "y"}, [][]string{{"strike"}, {"strong"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			paraContent := Convert(tt.input)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != len(tt.texts) {
+				t.Fatalf("expected %d nodes, got %d", len(tt.texts), len(paraContent))
+			}
+			for i, node := range paraContent {
+				assertText(t, node, tt.texts[i])
+				assertMarks(t, node, tt.marks[i]...)
+			}
+		})
+	}
+}
+
 func TestConvert_InlineCode(t *testing.T) {
 	result := Convert("Use `fmt.Println()` here")
 	content := result["content"].([]Node)
@@ -184,6 +459,32 @@ func TestConvert_InlineCode(t *testing.T) {
 	}
 }
 
+func TestConvert_CodeSpanBackticks(t *testing.T) {
+	tests := []struct {
+		input string
+		code  string
+	}{
+		{"`` `code` ``", "`code`"},
+		{"``a ` b``", "a ` b"},
+		{"` a `", "a"},
+		{"`  a  `", " a "},
+		{"`  `", "  "},
+		{"`foo\nbar`", "foo bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := Convert(tt.input)
+			paraContent := result["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 1 {
+				t.Fatalf("expected 1 node, got %d", len(paraContent))
+			}
+			assertText(t, paraContent[0], tt.code)
+			assertMarks(t, paraContent[0], "code")
+		})
+	}
+}
+
 func TestConvert_Link(t *testing.T) {
 	result := Convert("Click [here](https://example.com) for more")
 	content := result["content"].([]Node)
@@ -218,6 +519,274 @@ func TestConvert_Link(t *testing.T) {
 	}
 }
 
+func TestConvert_InlineCodeInsideLink(t *testing.T) {
+	result := Convert("[see `Foo`](https://example.com)")
+	paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+	if len(paraContent) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(paraContent))
+	}
+	assertText(t, paraContent[0], "see ")
+	assertMarks(t, paraContent[0], "link")
+	assertText(t, paraContent[1], "Foo")
+	assertMarks(t, paraContent[1], "link", "code")
+
+	attrs := paraContent[1]["marks"].([]Node)[0]["attrs"].(Node)
+	if attrs["href"] != "https://example.com" {
+		t.Errorf("expected href 'https://example.com', got %v", attrs["href"])
+	}
+}
+
+func TestConvert_InlineCodeInsideBoldLink(t *testing.T) {
+	result := Convert("[**bold `code`**](https://example.com)")
+	paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+	if len(paraContent) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(paraContent))
+	}
+	assertText(t, paraContent[0], "bold ")
+	assertMarks(t, paraContent[0], "link", "strong")
+	assertText(t, paraContent[1], "code")
+	assertMarks(t, paraContent[1], "link", "code", "strong")
+}
+
+func TestConvert_EmphasisInsideLink(t *testing.T) {
+	result := Convert("[*__x__*](https://example.com)")
+	paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+	if len(paraContent) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(paraContent))
+	}
+	assertText(t, paraContent[0], "x")
+	assertMarks(t, paraContent[0], "link", "strong", "em")
+}
+
+func TestConvert_CanonicalMarkOrder(t *testing.T) {
+	// However the Markdown nests them, marks come out as link, code,
+	// strong, em, strike, then the rest.
+	tests := []struct {
+		markdown string
+		marks    []string
+	}{
+		{"***bold italic***", []string{"strong", "em"}},
+		{"*__bold italic__*", []string{"strong", "em"}},
+		{"__*bold italic*__", []string{"strong", "em"}},
+		{"~~*__[x](https://example.com)__*~~", []string{"link", "strong", "em", "strike"}},
+		{"==**`x`**==", []string{"code", "strong", "backgroundColor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			para := Convert(tt.markdown)["content"].([]Node)[0]
+			nodes := collectNodes(para, "text")
+			if len(nodes) != 1 {
+				t.Fatalf("expected 1 text node, got %d", len(nodes))
+			}
+			assertMarks(t, nodes[0], tt.marks...)
+		})
+	}
+
+	// Runs with the same marks in a different source order merge.
+	content := Convert("***a***__*b*__")["content"].([]Node)[0]["content"].([]Node)
+	if len(content) != 1 {
+		t.Fatalf("expected 1 merged node, got %d", len(content))
+	}
+	assertText(t, content[0], "ab")
+}
+
+func TestConvert_NestedIdenticalEmphasis(t *testing.T) {
+	tests := []struct {
+		markdown string
+		marks    []string
+	}{
+		{"***__x__***", []string{"strong", "em"}},
+		{"**a **b** c**", []string{"strong"}},
+		{"*a _b_ c*", []string{"em"}},
+		{"~~a ~~b~~ c~~", []string{"strike"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			para := Convert(tt.markdown)["content"].([]Node)[0]
+			for _, node := range collectNodes(para, "text") {
+				assertMarks(t, node, tt.marks...)
+			}
+		})
+	}
+}
+
+func TestConvert_AdjacentIdenticalMarksMerge(t *testing.T) {
+	tests := []struct {
+		markdown string
+		text     string
+	}{
+		{"**a**__b__", "ab"},
+		{"**a **b** a**", "a b a"},
+		{"[**a**](https://example.com)**[b](https://example.com)**", "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			content := Convert(tt.markdown)["content"].([]Node)[0]["content"].([]Node)
+			if len(content) != 1 {
+				t.Fatalf("expected 1 merged node, got %v", content)
+			}
+			assertText(t, content[0], tt.text)
+		})
+	}
+}
+
+func TestConvert_NoDuplicateMarks(t *testing.T) {
+	inputs := []string{
+		"**a **b *c **d** c* b** a**",
+		"__**x**__",
+		"**<b>x</b>**",
+		"<strong>**x**</strong>",
+		"<kbd>`x`</kbd>",
+		"~~a ~~**b**~~ a~~",
+		"[**a [b](https://b.com)**](https://a.com)",
+	}
+	for _, md := range inputs {
+		t.Run(md, func(t *testing.T) {
+			for _, node := range collectNodes(Convert(md), "text") {
+				seen := map[any]bool{}
+				marks, _ := node["marks"].([]Node)
+				for _, mark := range marks {
+					if seen[mark["type"]] {
+						t.Errorf("duplicate %v mark on %v", mark["type"], node)
+					}
+					seen[mark["type"]] = true
+				}
+			}
+		})
+	}
+
+	// Marks appended without addMark are deduplicated too, keeping the
+	// outermost one.
+	outer := Node{"type": "link", "attrs": Node{"href": "https://a.com"}}
+	node := newTextNode("x", []Node{outer, {"type": "strong"}, {"type": "strong"}, {"type": "link", "attrs": Node{"href": "https://b.com"}}})
+	assertMarks(t, node, "link", "strong")
+	if href := node["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://a.com" {
+		t.Errorf("expected outer link kept, got %v", href)
+	}
+}
+
+func TestConvert_IssueKeyLinking(t *testing.T) {
+	const base = "https://example.atlassian.net/browse/"
+
+	t.Run("mid sentence", func(t *testing.T) {
+		result := Convert("Fixed in DEV-123 yesterday", WithIssueKeyLinking(base))
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Fixed in ")
+		assertType(t, paraContent[1], "inlineCard")
+		if url := paraContent[1]["attrs"].(Node)["url"]; url != base+"DEV-123" {
+			t.Errorf("expected url %q, got %v", base+"DEV-123", url)
+		}
+		assertText(t, paraContent[2], " yesterday")
+	})
+
+	t.Run("adjacent punctuation", func(t *testing.T) {
+		result := Convert("See (OPS-7), then ABC-42.", WithIssueKeyLinking(base))
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		var urls []any
+		var text string
+		for _, n := range paraContent {
+			if n["type"] == "inlineCard" {
+				urls = append(urls, n["attrs"].(Node)["url"])
+			} else {
+				text += n["text"].(string)
+			}
+		}
+		if fmt.Sprint(urls) != fmt.Sprint([]any{base + "OPS-7", base + "ABC-42"}) {
+			t.Errorf("unexpected card urls %v", urls)
+		}
+		if text != "See (), then ." {
+			t.Errorf("expected surrounding text 'See (), then .', got %q", text)
+		}
+	})
+
+	t.Run("ignored in code", func(t *testing.T) {
+		result := Convert("Run `DEV-1`\n\n```\nDEV-2\n```", WithIssueKeyLinking(base))
+		for _, n := range collectNodes(result, "inlineCard") {
+			t.Errorf("unexpected inlineCard %v", n)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := Convert("Fixed in DEV-123")
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "Fixed in DEV-123")
+	})
+}
+
+func TestConvert_LinkHrefNormalization(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+		href  string
+	}{
+		{"surrounding whitespace", "[a](  https://x.com  )", nil, "https://x.com"},
+		{"angle brackets", "[a](<https://x.com/a b>)", nil, "https://x.com/a b"},
+		{"relative without base", "[a](/relative)", nil, "/relative"},
+		{"relative with base", "[a](/relative)", []Option{WithBaseURL("https://x.com/docs/")}, "https://x.com/relative"},
+		{"path relative with base", "[a](setup.md)", []Option{WithBaseURL("https://x.com/docs/")}, "https://x.com/docs/setup.md"},
+		{"absolute with base", "[a](https://y.com/p)", []Option{WithBaseURL("https://x.com/")}, "https://y.com/p"},
+		{"invalid base ignored", "[a](/relative)", []Option{WithBaseURL("not a base")}, "/relative"},
+		{"relative image with base", "![a](/img.png)", []Option{WithBaseURL("https://x.com/")}, "https://x.com/img.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Convert(tt.input, tt.opts...)
+			node := result["content"].([]Node)[0]["content"].([]Node)[0]
+			assertText(t, node, "a")
+			attrs := node["marks"].([]Node)[0]["attrs"].(Node)
+			if attrs["href"] != tt.href {
+				t.Errorf("expected href %q, got %v", tt.href, attrs["href"])
+			}
+		})
+	}
+}
+
+func TestConvert_UnicodeText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"🎉 see https://x.com 日本語", []string{"🎉 see ", "<card:https://x.com>", " 日本語"}},
+		{"中文[链接](https://x.com)😀 **粗体**", []string{"中文", "链接", "😀 ", "粗体"}},
+		{"Grüße 👋🏽 from Zürich: user@例え.jp", []string{"Grüße 👋🏽 from Zürich: user@例え.jp"}},
+		{"🇩🇪~~删除~~👨‍👩‍👧", []string{"🇩🇪", "删除", "👨‍👩‍👧"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			paraContent := Convert(tt.input)["content"].([]Node)[0]["content"].([]Node)
+			var got []string
+			for _, n := range paraContent {
+				if n["type"] == "inlineCard" {
+					got = append(got, "<card:"+n["attrs"].(Node)["url"].(string)+">")
+					continue
+				}
+				text := n["text"].(string)
+				if !utf8.ValidString(text) {
+					t.Errorf("invalid UTF-8 in text node %q", text)
+				}
+				got = append(got, text)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestConvert_Blockquote(t *testing.T) {
 	result := Convert("> This is a quote")
 	content := result["content"].([]Node)
@@ -231,6 +800,79 @@ func TestConvert_Blockquote(t *testing.T) {
 	}
 }
 
+func TestConvert_BlockquoteHardBreak(t *testing.T) {
+	for name, input := range map[string]string{
+		"trailing spaces": "> line one  \n> line two",
+		"backslash":       "> line one\\\n> line two",
+	} {
+		t.Run(name, func(t *testing.T) {
+			quote := Convert(input)["content"].([]Node)[0]
+			assertType(t, quote, "blockquote")
+
+			quoteContent := quote["content"].([]Node)
+			if len(quoteContent) != 1 {
+				t.Fatalf("expected 1 paragraph, got %d", len(quoteContent))
+			}
+			assertType(t, quoteContent[0], "paragraph")
+
+			paraContent := quoteContent[0]["content"].([]Node)
+			if len(paraContent) != 3 {
+				t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+			}
+			assertText(t, paraContent[0], "line one")
+			assertType(t, paraContent[1], "hardBreak")
+			assertText(t, paraContent[2], "line two")
+		})
+	}
+}
+
+func TestConvert_BlockquoteMixedContent(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		quote := Convert("> Action items:\n> - ship **it**\n>   - nested\n> - review")["content"].([]Node)[0]
+		assertType(t, quote, "blockquote")
+		content := quote["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+		assertType(t, content[1], "bulletList")
+		items := content[1]["content"].([]Node)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		first := items[0]["content"].([]Node)
+		if len(first) != 2 {
+			t.Fatalf("expected paragraph and nested list, got %d nodes", len(first))
+		}
+		assertMarks(t, first[0]["content"].([]Node)[1], "strong")
+		assertType(t, first[1], "bulletList")
+	})
+
+	t.Run("ordered list", func(t *testing.T) {
+		quote := Convert("> 1. first\n> 2. second")["content"].([]Node)[0]
+		content := quote["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		assertType(t, content[0], "orderedList")
+	})
+
+	t.Run("code", func(t *testing.T) {
+		quote := Convert("> Run this:\n>\n> ```sh\n> make test\n> ```\n>\n>     indented")["content"].([]Node)[0]
+		content := quote["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(content))
+		}
+		assertType(t, content[1], "codeBlock")
+		if lang := content[1]["attrs"].(Node)["language"]; lang != "sh" {
+			t.Errorf("expected language 'sh', got %v", lang)
+		}
+		assertText(t, content[1]["content"].([]Node)[0], "make test")
+		assertType(t, content[2], "codeBlock")
+		assertText(t, content[2]["content"].([]Node)[0], "indented")
+	})
+}
+
 func TestConvert_ComplexDocument(t *testing.T) {
 	input := "# Project Update\n\n" +
 		"This is a **summary** of the work done.\n\n" +
@@ -275,6 +917,81 @@ func TestConvert_ThematicBreak(t *testing.T) {
 	assertType(t, content[2], "paragraph")
 }
 
+func TestConvert_ThematicBreakForms(t *testing.T) {
+	for _, form := range []string{"---", "***", "___", "- - -", "* * *", "_ _ _", "----------", "   ***"} {
+		t.Run(form, func(t *testing.T) {
+			content := Convert("Above\n\n" + form + "\n\nBelow")["content"].([]Node)
+			if len(content) != 3 {
+				t.Fatalf("expected 3 nodes, got %d", len(content))
+			}
+			assertText(t, content[0]["content"].([]Node)[0], "Above")
+			assertType(t, content[1], "rule")
+			if _, ok := content[1]["content"]; ok {
+				t.Errorf("expected rule without content, got %v", content[1])
+			}
+			assertText(t, content[2]["content"].([]Node)[0], "Below")
+		})
+	}
+
+	// Directly below a paragraph, a dash line underlines a setext heading,
+	// while stars and underscores still form a rule.
+	content := Convert("Title\n---")["content"].([]Node)
+	assertType(t, content[0], "heading")
+	for _, form := range []string{"***", "___"} {
+		content := Convert("Above\n" + form + "\nBelow")["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("%s: expected 3 nodes, got %d", form, len(content))
+		}
+		assertType(t, content[1], "rule")
+	}
+}
+
+func TestConvert_MultipleThematicBreaks(t *testing.T) {
+	t.Run("separated by paragraphs", func(t *testing.T) {
+		content := Convert("A\n\n---\n\nB\n\n***\n\nC\n\n___\n\nD")["content"].([]Node)
+		want := []string{"paragraph", "rule", "paragraph", "rule", "paragraph", "rule", "paragraph"}
+		if len(content) != len(want) {
+			t.Fatalf("expected %d nodes, got %d", len(want), len(content))
+		}
+		for i, typ := range want {
+			assertType(t, content[i], typ)
+		}
+		for i, text := range []string{"A", "B", "C", "D"} {
+			assertText(t, content[2*i]["content"].([]Node)[0], text)
+		}
+	})
+
+	t.Run("adjacent", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  int
+		}{
+			{"---\n---", 2},
+			{"***\n\n***", 2},
+			{"---\n***\n\n- - -", 3},
+		}
+		for _, tt := range tests {
+			content := Convert(tt.input)["content"].([]Node)
+			if len(content) != tt.want {
+				t.Fatalf("%q: expected %d rules, got %d nodes", tt.input, tt.want, len(content))
+			}
+			for _, node := range content {
+				assertType(t, node, "rule")
+			}
+		}
+	})
+
+	t.Run("after setext heading", func(t *testing.T) {
+		// The first dash line underlines the heading; the second is a rule.
+		content := Convert("Title\n---\n---")["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected heading and rule, got %d nodes", len(content))
+		}
+		assertType(t, content[0], "heading")
+		assertType(t, content[1], "rule")
+	})
+}
+
 func TestConvert_Image(t *testing.T) {
 	result := Convert("![alt text](https://example.com/img.png)")
 	content := result["content"].([]Node)
@@ -324,6 +1041,37 @@ func TestConvert_Strikethrough(t *testing.T) {
 	}
 }
 
+func TestConvert_SingleTildeStrike(t *testing.T) {
+	tests := []struct {
+		markdown string
+		opts     []Option
+		struck   string
+	}{
+		{"takes ~5 minutes~ tops", nil, ""},
+		{"a ~~b~~ c", nil, "b"},
+		{"a ~~~b~~~ c", nil, ""},
+		{"takes ~5 minutes~ tops", []Option{WithSingleTildeStrike()}, "5 minutes"},
+		{"a ~~b~~ c", []Option{WithSingleTildeStrike()}, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.markdown, len(tt.opts)), func(t *testing.T) {
+			para := Convert(tt.markdown, tt.opts...)["content"].([]Node)[0]
+			var struck string
+			for _, node := range collectNodes(para, "text") {
+				if marks, ok := node["marks"].([]Node); ok && hasMark(marks, "strike") {
+					struck += node["text"].(string)
+				}
+			}
+			if struck != tt.struck {
+				t.Errorf("expected struck text %q, got %q", tt.struck, struck)
+			}
+			if tt.struck == "" && plainText(para["content"].([]Node)) != tt.markdown {
+				t.Errorf("expected literal text %q, got %q", tt.markdown, plainText(para["content"].([]Node)))
+			}
+		})
+	}
+}
+
 func TestConvert_Table(t *testing.T) {
 	input := "| Name | Age |\n| --- | --- |\n| Alice | 30 |\n| Bob | 25 |"
 
@@ -402,6 +1150,446 @@ func TestConvert_TableWithFormatting(t *testing.T) {
 	}
 }
 
+func TestConvert_TableHeaderOnly(t *testing.T) {
+	result := Convert("| Name | Age |\n| --- | --- |")
+	table := result["content"].([]Node)[0]
+	assertType(t, table, "table")
+
+	rows := table["content"].([]Node)
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 header row, got %d", len(rows))
+	}
+	cells := rows[0]["content"].([]Node)
+	if len(cells) != 2 {
+		t.Fatalf("expected 2 header cells, got %d", len(cells))
+	}
+	for _, cell := range cells {
+		assertType(t, cell, "tableHeader")
+	}
+}
+
+func TestConvert_TableEmptyHeaderCell(t *testing.T) {
+	// The empty first header only carries the column's alignment.
+	table := Convert("|  | Total |\n|:-:|--:|\n| Q1 | 10 |")["content"].([]Node)[0]
+	rows := table["content"].([]Node)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+
+	header := rows[0]["content"].([]Node)
+	if len(header) != 2 {
+		t.Fatalf("expected 2 header cells, got %d", len(header))
+	}
+	assertType(t, header[0], "tableHeader")
+	para := header[0]["content"].([]Node)
+	if len(para) != 1 {
+		t.Fatalf("expected 1 paragraph in empty header, got %d", len(para))
+	}
+	assertType(t, para[0], "paragraph")
+	if inline := para[0]["content"].([]Node); len(inline) != 0 {
+		t.Errorf("expected empty paragraph, got %v", inline)
+	}
+	assertText(t, header[1]["content"].([]Node)[0]["content"].([]Node)[0], "Total")
+
+	cells := rows[1]["content"].([]Node)
+	if len(cells) != 2 {
+		t.Fatalf("expected 2 data cells, got %d", len(cells))
+	}
+	assertText(t, cells[0]["content"].([]Node)[0]["content"].([]Node)[0], "Q1")
+	assertText(t, cells[1]["content"].([]Node)[0]["content"].([]Node)[0], "10")
+}
+
+func TestConvert_TableRaggedRow(t *testing.T) {
+	result := Convert("| A | B | C |\n| --- | --- | --- |\n| 1 |\n| 1 | 2 | 3 | 4 |")
+	table := result["content"].([]Node)[0]
+
+	rows := table["content"].([]Node)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		cells := row["content"].([]Node)
+		if len(cells) != 3 {
+			t.Fatalf("row %d: expected 3 cells, got %d", i, len(cells))
+		}
+	}
+
+	short := rows[1]["content"].([]Node)
+	assertText(t, short[0]["content"].([]Node)[0]["content"].([]Node)[0], "1")
+	for _, cell := range short[1:] {
+		assertType(t, cell, "tableCell")
+		para := cell["content"].([]Node)[0]
+		assertType(t, para, "paragraph")
+		if n := len(para["content"].([]Node)); n != 0 {
+			t.Errorf("expected padded cell to be empty, got %d nodes", n)
+		}
+	}
+}
+
+func TestConvert_WhitespaceOnlyParagraphs(t *testing.T) {
+	for _, md := range []string{"&#32;&#32;&#32;", "\u00a0\u00a0", "&nbsp;", "&nbsp;\\\n&#9;"} {
+		content := Convert("Before\n\n" + md + "\n\nAfter")["content"].([]Node)
+		if len(content) != 2 {
+			t.Errorf("%q: expected whitespace-only paragraph dropped, got %d nodes", md, len(content))
+		}
+	}
+
+	// With empty paragraphs preserved, the blank paragraph keeps its slot
+	// but loses its whitespace.
+	content := Convert("Before\n\n&#32;&#32;\n\nAfter", WithPreserveEmptyParagraphs())["content"].([]Node)
+	if len(content) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(content))
+	}
+	if _, ok := content[1]["content"]; ok {
+		t.Errorf("expected empty paragraph without content, got %v", content[1])
+	}
+
+	content = Convert("Before\n\n&nbsp;\n\nAfter", WithNbspSpacers())["content"].([]Node)
+	if len(content) != 3 {
+		t.Fatalf("expected 3 nodes with nbsp spacer kept, got %d", len(content))
+	}
+	assertText(t, content[1]["content"].([]Node)[0], "\u00a0")
+
+	// Plain spaces are not spacers, even with WithNbspSpacers.
+	content = Convert("Before\n\n&#32;\n\nAfter", WithNbspSpacers())["content"].([]Node)
+	if len(content) != 2 {
+		t.Errorf("expected 2 nodes, got %d", len(content))
+	}
+}
+
+func TestConvert_TableLayout(t *testing.T) {
+	md := "| A |\n| --- |\n| 1 |"
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "default"},
+		{[]Option{WithTableLayout("full-width")}, "full-width"},
+		{[]Option{WithTableLayout("wide")}, "wide"},
+		{[]Option{WithTableLayout("center")}, "center"},
+		{[]Option{WithTableLayout("huge")}, "default"},
+		{[]Option{WithTableLayout("wide"), WithTableLayout("bogus")}, "default"},
+	}
+	for _, tt := range tests {
+		table := Convert(md, tt.opts...)["content"].([]Node)[0]
+		if got := table["attrs"].(Node)["layout"]; got != tt.want {
+			t.Errorf("expected layout %q, got %v", tt.want, got)
+		}
+	}
+
+	html := "<table><tr><td>x</td></tr></table>"
+	table := Convert(html, WithTableLayout("full-width"))["content"].([]Node)[0]
+	assertType(t, table, "table")
+	if got := table["attrs"].(Node)["layout"]; got != "full-width" {
+		t.Errorf("expected HTML table layout full-width, got %v", got)
+	}
+}
+
+func TestConvert_TableNumberColumn(t *testing.T) {
+	md := "| A | B |\n| --- | --- |\n| 1 | 2 |"
+	for _, enabled := range []bool{false, true} {
+		table := Convert(md, WithTableNumberColumn(enabled))["content"].([]Node)[0]
+		if got := table["attrs"].(Node)["isNumberColumnEnabled"]; got != enabled {
+			t.Errorf("expected isNumberColumnEnabled %v, got %v", enabled, got)
+		}
+		// The number column is rendered, not stored: rows keep their cells.
+		for i, row := range table["content"].([]Node) {
+			if n := len(row["content"].([]Node)); n != 2 {
+				t.Errorf("enabled=%v row %d: expected 2 cells, got %d", enabled, i, n)
+			}
+		}
+	}
+}
+
+func TestConvert_TablesAsList(t *testing.T) {
+	md := "| Name | Role |\n| --- | --- |\n| Alice | **Lead** |\n| Bob | |\n| | |"
+	list := Convert(md, WithTablesAsList())["content"].([]Node)[0]
+	assertType(t, list, "bulletList")
+
+	items := list["content"].([]Node)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+
+	pairs := items[0]["content"].([]Node)
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %d", len(pairs))
+	}
+	name := pairs[0]["content"].([]Node)
+	assertText(t, name[0], "Name:")
+	assertMarks(t, name[0], "strong")
+	assertText(t, name[1], " Alice")
+	assertMarks(t, name[1])
+	role := pairs[1]["content"].([]Node)
+	assertText(t, role[0], "Role:")
+	assertText(t, role[2], "Lead")
+	assertMarks(t, role[2], "strong")
+
+	// Empty cells are left out.
+	pairs = items[1]["content"].([]Node)
+	if len(pairs) != 1 {
+		t.Fatalf("expected 1 pair, got %d", len(pairs))
+	}
+	if got := plainText(pairs[0]["content"].([]Node)); got != "Name: Bob" {
+		t.Errorf("expected %q, got %q", "Name: Bob", got)
+	}
+
+	t.Run("header only", func(t *testing.T) {
+		// The header labels are kept as a single item.
+		for _, md := range []string{"| A | B |\n| --- | --- |", "| A | B |\n| --- | --- |\n|  |  |"} {
+			content := Convert(md, WithTablesAsList())["content"].([]Node)
+			if len(content) != 1 {
+				t.Fatalf("%q: expected 1 node, got %v", md, content)
+			}
+			items := content[0]["content"].([]Node)
+			if len(items) != 1 {
+				t.Fatalf("%q: expected 1 item, got %d", md, len(items))
+			}
+			labels := items[0]["content"].([]Node)
+			if len(labels) != 2 {
+				t.Fatalf("%q: expected 2 labels, got %d", md, len(labels))
+			}
+			for i, want := range []string{"A", "B"} {
+				text := labels[i]["content"].([]Node)[0]
+				assertText(t, text, want)
+				assertMarks(t, text, "strong")
+			}
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		doc, diags := ConvertWithDiagnostics("|  |  |\n| --- | --- |\n|  |  |", WithTablesAsList())
+		if content := doc["content"].([]Node); len(content) != 0 {
+			t.Errorf("expected no content, got %v", content)
+		}
+		if len(diags) != 1 || diags[0].Message != "table without content dropped" {
+			t.Errorf("expected a diagnostic for the dropped table, got %v", diags)
+		}
+	})
+}
+
+func TestConvert_TableOuterPipes(t *testing.T) {
+	inputs := map[string]string{
+		"with pipes":    "| a | b |\n| --- | --- |\n| 1 | 2 |",
+		"without pipes": "a | b\n--- | ---\n1 | 2",
+		"leading only":  "| a | b\n| --- | ---\n| 1 | 2",
+		"trailing only": "a | b |\n--- | --- |\n1 | 2 |",
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			result := Convert(input)
+			table := result["content"].([]Node)[0]
+			assertType(t, table, "table")
+
+			rows := table["content"].([]Node)
+			if len(rows) != 2 {
+				t.Fatalf("expected 2 rows, got %d", len(rows))
+			}
+			want := [][]string{{"a", "b"}, {"1", "2"}}
+			for i, row := range rows {
+				cells := row["content"].([]Node)
+				if len(cells) != 2 {
+					t.Fatalf("row %d: expected 2 cells, got %d", i, len(cells))
+				}
+				for j, cell := range cells {
+					text := cell["content"].([]Node)[0]["content"].([]Node)[0]
+					assertText(t, text, want[i][j])
+				}
+			}
+		})
+	}
+}
+
+func TestConvert_TableEscapedPipe(t *testing.T) {
+	result := Convert("| expr | code |\n| --- | --- |\n| a \\| b | `x \\| y` |")
+	rows := result["content"].([]Node)[0]["content"].([]Node)
+	cells := rows[1]["content"].([]Node)
+	if len(cells) != 2 {
+		t.Fatalf("expected 2 cells, got %d", len(cells))
+	}
+
+	plain := cells[0]["content"].([]Node)[0]["content"].([]Node)
+	if len(plain) != 1 {
+		t.Fatalf("expected 1 text node, got %d", len(plain))
+	}
+	assertText(t, plain[0], "a | b")
+
+	code := cells[1]["content"].([]Node)[0]["content"].([]Node)[0]
+	assertText(t, code, "x | y")
+	assertMarks(t, code, "code")
+}
+
+func TestConvert_TableCellLinks(t *testing.T) {
+	// Cells are converted like paragraphs, so bare URLs become cards and
+	// email addresses mailto links, with or without surrounding spaces.
+	for _, md := range []string{
+		"| Link | Contact |\n| --- | --- |\n| https://example.com/x | me@example.com |",
+		"| Link | Contact |\n| --- | --- |\n|https://example.com/x|<me@example.com>|",
+	} {
+		table := Convert(md)["content"].([]Node)[0]
+		cells := table["content"].([]Node)[1]["content"].([]Node)
+
+		link := cells[0]["content"].([]Node)[0]["content"].([]Node)
+		if len(link) != 1 {
+			t.Fatalf("%q: expected 1 node in link cell, got %d", md, len(link))
+		}
+		assertType(t, link[0], "inlineCard")
+		if url := link[0]["attrs"].(Node)["url"]; url != "https://example.com/x" {
+			t.Errorf("expected card url 'https://example.com/x', got %v", url)
+		}
+
+		contact := cells[1]["content"].([]Node)[0]["content"].([]Node)
+		if len(contact) != 1 {
+			t.Fatalf("%q: expected 1 node in contact cell, got %d", md, len(contact))
+		}
+		assertText(t, contact[0], "me@example.com")
+		assertMarks(t, contact[0], "link")
+		if href := contact[0]["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "mailto:me@example.com" {
+			t.Errorf("expected href 'mailto:me@example.com', got %v", href)
+		}
+	}
+}
+
+func TestConvert_TableCellBlocks(t *testing.T) {
+	cellContent := func(t *testing.T, cell string) []Node {
+		t.Helper()
+		table := Convert("| h |\n| --- |\n| " + cell + " |")["content"].([]Node)[0]
+		row := table["content"].([]Node)[1]
+		return row["content"].([]Node)[0]["content"].([]Node)
+	}
+
+	t.Run("two paragraphs", func(t *testing.T) {
+		content := cellContent(t, "First para<br><br>Second **para**")
+		if len(content) != 2 {
+			t.Fatalf("expected 2 blocks, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+		assertText(t, content[0]["content"].([]Node)[0], "First para")
+		assertType(t, content[1], "paragraph")
+		second := content[1]["content"].([]Node)
+		assertText(t, second[0], "Second ")
+		assertText(t, second[1], "para")
+		assertMarks(t, second[1], "strong")
+	})
+
+	t.Run("line break", func(t *testing.T) {
+		content := cellContent(t, "line one<br/>line two")
+		if len(content) != 1 {
+			t.Fatalf("expected 1 block, got %d", len(content))
+		}
+		inline := content[0]["content"].([]Node)
+		if len(inline) != 3 {
+			t.Fatalf("expected 3 inline nodes, got %d", len(inline))
+		}
+		assertText(t, inline[0], "line one")
+		assertType(t, inline[1], "hardBreak")
+		assertText(t, inline[2], "line two")
+	})
+
+	t.Run("bullets", func(t *testing.T) {
+		content := cellContent(t, "Steps:<br>• one<br>• *two*<br><br>Done")
+		if len(content) != 3 {
+			t.Fatalf("expected 3 blocks, got %d", len(content))
+		}
+		assertType(t, content[0], "paragraph")
+		assertType(t, content[1], "bulletList")
+		items := content[1]["content"].([]Node)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		assertText(t, items[0]["content"].([]Node)[0]["content"].([]Node)[0], "one")
+		two := items[1]["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, two, "two")
+		assertMarks(t, two, "em")
+		assertType(t, content[2], "paragraph")
+	})
+
+	t.Run("only breaks", func(t *testing.T) {
+		content := cellContent(t, "<br><br>")
+		if len(content) != 1 || len(content[0]["content"].([]Node)) != 0 {
+			t.Errorf("expected a single empty paragraph, got %v", content)
+		}
+	})
+}
+
+func TestConvert_ControlCharacters(t *testing.T) {
+	input := "a\x00b\x01c\x7fd \u0085e\xff\n\n`x\x00y`\n\n```\nline\x07 one\r\nline two\n```\n\n[li\x00nk](https://example.com/\x01a)"
+	doc := Convert(input)
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !json.Valid(out) {
+		t.Fatalf("invalid JSON: %s", out)
+	}
+	for _, escaped := range []string{`\u0000`, `\u0001`, `\u0007`, `\r`, "\x7f", "\u0085"} {
+		if strings.Contains(string(out), escaped) {
+			t.Errorf("expected %q to be stripped, got %s", escaped, out)
+		}
+	}
+
+	content := doc["content"].([]Node)
+	assertText(t, content[0]["content"].([]Node)[0], "abcd e\ufffd")
+	assertText(t, content[1]["content"].([]Node)[0], "xy")
+	assertText(t, content[2]["content"].([]Node)[0], "line one\nline two")
+	link := content[3]["content"].([]Node)[0]
+	assertText(t, link, "link")
+	if href := link["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://example.com/a" {
+		t.Errorf("expected sanitized href, got %q", href)
+	}
+}
+
+func TestConvert_BackslashEscapes(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{`\*not italic\*`, "*not italic*"},
+		{`\_not italic\_`, "_not italic_"},
+		{`\[not a link\](target)`, "[not a link](target)"},
+		{`back\\slash`, `back\slash`},
+		{`\# not a heading`, "# not a heading"},
+		{`C:\path\to`, `C:\path\to`},
+		{`AT&amp;T &lt;tag&gt; &copy; &#65;&#x42;`, "AT&T <tag> © AB"},
+		{`\&amp; &unknown; &#0;`, "&amp; &unknown; \uFFFD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 1 {
+				t.Fatalf("expected 1 node, got %d: %v", len(paraContent), paraContent)
+			}
+			assertText(t, paraContent[0], tt.want)
+			assertMarks(t, paraContent[0])
+		})
+	}
+
+	t.Run("escaped backslash before emphasis", func(t *testing.T) {
+		paraContent := Convert(`\\*em*`)["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], `\`)
+		assertText(t, paraContent[1], "em")
+		assertMarks(t, paraContent[1], "em")
+	})
+
+	t.Run("link destination", func(t *testing.T) {
+		node := Convert(`[a\*b](https://example.com/\*?x=1&amp;y=2)`)["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, node, "a*b")
+		href := node["marks"].([]Node)[0]["attrs"].(Node)["href"]
+		if href != "https://example.com/*?x=1&y=2" {
+			t.Errorf("expected decoded href, got %v", href)
+		}
+	})
+
+	t.Run("code keeps backslashes", func(t *testing.T) {
+		node := Convert("`\\*`")["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, node, `\*`)
+	})
+}
+
 func TestConvert_NestedList(t *testing.T) {
 	input := "- Item 1\n  - Nested A\n  - Nested B\n- Item 2"
 
@@ -424,10 +1612,132 @@ func TestConvert_NestedList(t *testing.T) {
 	assertType(t, item1Content[0], "paragraph")
 	assertType(t, item1Content[1], "bulletList")
 
-	nestedItems := item1Content[1]["content"].([]Node)
-	if len(nestedItems) != 2 {
-		t.Fatalf("expected 2 nested items, got %d", len(nestedItems))
+	nestedItems := item1Content[1]["content"].([]Node)
+	if len(nestedItems) != 2 {
+		t.Fatalf("expected 2 nested items, got %d", len(nestedItems))
+	}
+}
+
+func TestConvert_ListItemLineBreaks(t *testing.T) {
+	t.Run("single newline joins paragraph", func(t *testing.T) {
+		result := Convert("- line one\n  line two")
+		item := result["content"].([]Node)[0]["content"].([]Node)[0]
+		itemContent := item["content"].([]Node)
+
+		if len(itemContent) != 1 {
+			t.Fatalf("expected 1 paragraph, got %d", len(itemContent))
+		}
+		assertType(t, itemContent[0], "paragraph")
+		paraContent := itemContent[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 text node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "line one line two")
+	})
+
+	t.Run("blank line splits paragraphs", func(t *testing.T) {
+		result := Convert("- line one\n\n  line two")
+		item := result["content"].([]Node)[0]["content"].([]Node)[0]
+		itemContent := item["content"].([]Node)
+
+		if len(itemContent) != 2 {
+			t.Fatalf("expected 2 paragraphs, got %d", len(itemContent))
+		}
+		assertType(t, itemContent[0], "paragraph")
+		assertText(t, itemContent[0]["content"].([]Node)[0], "line one")
+		assertType(t, itemContent[1], "paragraph")
+		assertText(t, itemContent[1]["content"].([]Node)[0], "line two")
+	})
+}
+
+func TestConvert_DeeplyNestedList(t *testing.T) {
+	t.Run("three levels mixed", func(t *testing.T) {
+		input := "- Level 1\n  1. Level 2\n     - Level 3\n- Sibling"
+		result := Convert(input)
+		list := result["content"].([]Node)[0]
+		assertType(t, list, "bulletList")
+
+		want := []string{"orderedList", "bulletList"}
+		item := list["content"].([]Node)[0]
+		for depth, listType := range want {
+			itemContent := item["content"].([]Node)
+			if len(itemContent) != 2 {
+				t.Fatalf("depth %d: expected paragraph + nested list, got %d children", depth+1, len(itemContent))
+			}
+			assertType(t, itemContent[0], "paragraph")
+			assertType(t, itemContent[1], listType)
+			item = itemContent[1]["content"].([]Node)[0]
+		}
+		assertType(t, item, "listItem")
+		assertText(t, item["content"].([]Node)[0]["content"].([]Node)[0], "Level 3")
+
+		if n := len(list["content"].([]Node)); n != 2 {
+			t.Errorf("expected 2 top-level items, got %d", n)
+		}
+	})
+
+	t.Run("four levels", func(t *testing.T) {
+		input := "1. One\n   - Two\n     1. Three\n        - Four"
+		result := Convert(input)
+		list := result["content"].([]Node)[0]
+
+		types := []string{"orderedList", "bulletList", "orderedList", "bulletList"}
+		texts := []string{"One", "Two", "Three", "Four"}
+		for depth := range types {
+			assertType(t, list, types[depth])
+			items := list["content"].([]Node)
+			if len(items) != 1 {
+				t.Fatalf("depth %d: expected 1 item, got %d", depth+1, len(items))
+			}
+			itemContent := items[0]["content"].([]Node)
+			assertText(t, itemContent[0]["content"].([]Node)[0], texts[depth])
+			if depth == len(types)-1 {
+				if len(itemContent) != 1 {
+					t.Errorf("expected innermost item to hold only a paragraph, got %d children", len(itemContent))
+				}
+				break
+			}
+			if len(itemContent) != 2 {
+				t.Fatalf("depth %d: expected paragraph + nested list, got %d children", depth+1, len(itemContent))
+			}
+			list = itemContent[1]
+		}
+	})
+}
+
+func TestConvert_ListItemOnlyNestedList(t *testing.T) {
+	result := Convert("- \n  - nested\n- sibling")
+	items := result["content"].([]Node)[0]["content"].([]Node)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+
+	itemContent := items[0]["content"].([]Node)
+	if len(itemContent) != 1 {
+		t.Fatalf("expected only the nested list, got %d children", len(itemContent))
+	}
+	assertType(t, itemContent[0], "bulletList")
+	nested := itemContent[0]["content"].([]Node)[0]["content"].([]Node)[0]
+	assertText(t, nested["content"].([]Node)[0], "nested")
+
+	for _, para := range collectNodes(result, "paragraph") {
+		if content, _ := para["content"].([]Node); len(content) == 0 {
+			t.Error("expected no empty paragraphs")
+		}
+	}
+}
+
+func TestConvert_EmptyListItem(t *testing.T) {
+	result := Convert("- a\n-\n- c")
+	items := result["content"].([]Node)[0]["content"].([]Node)
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
 	}
+	itemContent := items[1]["content"].([]Node)
+	if len(itemContent) != 1 {
+		t.Fatalf("expected 1 placeholder paragraph, got %d children", len(itemContent))
+	}
+	assertType(t, itemContent[0], "paragraph")
 }
 
 func TestConvert_CodeBlockNoLanguage(t *testing.T) {
@@ -448,6 +1758,38 @@ func TestConvert_CodeBlockNoLanguage(t *testing.T) {
 	assertText(t, codeContent[0], "plain code")
 }
 
+func TestConvert_EmptyCodeBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		opts     []Option
+	}{
+		{"empty fence", "```\n```", nil},
+		{"blank line", "```go\n\n```", nil},
+		{"unclosed fence", "```", nil},
+		{"empty math", "$$\n$$", []Option{WithInlineMath(InlineMathCode)}},
+		{"empty diagram panel", "```mermaid\n```", []Option{WithDiagramMode(DiagramPanel)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := Convert(tt.markdown, tt.opts...)
+			blocks := collectNodes(doc, "codeBlock")
+			if len(blocks) != 1 {
+				t.Fatalf("expected 1 codeBlock, got %d", len(blocks))
+			}
+			content, ok := blocks[0]["content"].([]Node)
+			if !ok || len(content) != 0 {
+				t.Errorf("expected an empty content array, got %v", blocks[0]["content"])
+			}
+		})
+	}
+
+	t.Run("whitespace is kept", func(t *testing.T) {
+		codeBlock := Convert("```\n  \n\n```")["content"].([]Node)[0]
+		assertText(t, codeBlock["content"].([]Node)[0], "  \n")
+	})
+}
+
 func TestConvert_BoldItalicCombined(t *testing.T) {
 	result := Convert("This is ***bold and italic*** text")
 	content := result["content"].([]Node)
@@ -519,6 +1861,249 @@ func TestConvert_InlineCard_BareURL(t *testing.T) {
 	}
 }
 
+func TestConvert_InlineCard_TrailingPunctuation(t *testing.T) {
+	tests := []struct {
+		markdown string
+		before   string
+		url      string
+		after    string
+	}{
+		{"See https://example.com.", "See ", "https://example.com", "."},
+		{"Go to https://example.com, then", "Go to ", "https://example.com", ", then"},
+		{"(see https://example.com)", "(see ", "https://example.com", ")"},
+		{"(see https://example.com).", "(see ", "https://example.com", ")."},
+		{"(see https://example.com/path).", "(see ", "https://example.com/path", ")."},
+		{"Done: https://example.com;", "Done: ", "https://example.com", ";"},
+		{"Wiki: https://en.wikipedia.org/wiki/Go_(language).", "Wiki: ", "https://en.wikipedia.org/wiki/Go_(language)", "."},
+		{"(www.example.com/x).", "(", "http://www.example.com/x", ")."},
+		{"Query https://example.com/?q=1!", "Query ", "https://example.com/?q=1", "!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 3 {
+				t.Fatalf("expected 3 nodes, got %d: %v", len(paraContent), paraContent)
+			}
+			assertText(t, paraContent[0], tt.before)
+			assertType(t, paraContent[1], "inlineCard")
+			if url := paraContent[1]["attrs"].(Node)["url"]; url != tt.url {
+				t.Errorf("expected url %q, got %v", tt.url, url)
+			}
+			assertText(t, paraContent[2], tt.after)
+		})
+	}
+}
+
+func TestConvert_InlineCard_AfterMarkedText(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{"**bold** https://x.com more", `[{"marks":[{"type":"strong"}],"text":"bold","type":"text"},{"text":" ","type":"text"},{"attrs":{"url":"https://x.com"},"type":"inlineCard"},{"text":" more","type":"text"}]`},
+		{"**bold**https://x.com", `[{"marks":[{"type":"strong"}],"text":"bold","type":"text"},{"attrs":{"url":"https://x.com"},"type":"inlineCard"}]`},
+		{"*a* *b* https://x.com *c*", `[{"marks":[{"type":"em"}],"text":"a","type":"text"},{"text":" ","type":"text"},{"marks":[{"type":"em"}],"text":"b","type":"text"},{"text":" ","type":"text"},{"attrs":{"url":"https://x.com"},"type":"inlineCard"},{"text":" ","type":"text"},{"marks":[{"type":"em"}],"text":"c","type":"text"}]`},
+		{"plain https://x.com plain text https://y.com", `[{"text":"plain ","type":"text"},{"attrs":{"url":"https://x.com"},"type":"inlineCard"},{"text":" plain text ","type":"text"},{"attrs":{"url":"https://y.com"},"type":"inlineCard"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown)["content"].([]Node)[0]["content"].([]Node)
+			for _, node := range paraContent {
+				if node["type"] == "text" && node["text"] == "" {
+					t.Errorf("unexpected empty text node in %v", paraContent)
+				}
+			}
+			if got, _ := json.Marshal(paraContent); string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_UnclosedFormatting(t *testing.T) {
+	tests := []struct {
+		markdown string
+		opts     []Option
+		want     string
+	}{
+		{"**bold without close", nil, "**bold without close"},
+		{"*italic without close", nil, "*italic without close"},
+		{"__bold without close", nil, "__bold without close"},
+		{"~~strike without close", nil, "~~strike without close"},
+		{"`code without close", nil, "`code without close"},
+		{"[link without close", nil, "[link without close"},
+		{"[link](without close", nil, "[link](without close"},
+		{"{color:#ff0000}red without close", nil, "{color:#ff0000}red without close"},
+		{"x<sup>2 without close", nil, "x2 without close"},
+		{"press <kbd>Esc without close", []Option{WithKbdAsCode()}, "press Esc without close"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown, tt.opts...)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 1 {
+				t.Fatalf("expected 1 node, got %d: %v", len(paraContent), paraContent)
+			}
+			assertText(t, paraContent[0], tt.want)
+			assertMarks(t, paraContent[0])
+		})
+	}
+
+	t.Run("closed marks before unclosed", func(t *testing.T) {
+		paraContent := Convert("**done** and **open")["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "done")
+		assertMarks(t, paraContent[0], "strong")
+		assertText(t, paraContent[1], " and **open")
+		assertMarks(t, paraContent[1])
+	})
+}
+
+func TestConvert_BreakAfterClosingMarks(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{"**bold**\nnext", `[{"marks":[{"type":"strong"}],"text":"bold","type":"text"},{"text":" next","type":"text"}]`},
+		{"*em*  \nnext", `[{"marks":[{"type":"em"}],"text":"em","type":"text"},{"type":"hardBreak"},{"text":"next","type":"text"}]`},
+		{"[link](https://x.com)\\\nnext", `[{"marks":[{"attrs":{"href":"https://x.com"},"type":"link"}],"text":"link","type":"text"},{"type":"hardBreak"},{"text":"next","type":"text"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown)["content"].([]Node)[0]["content"].([]Node)
+			if got, _ := json.Marshal(paraContent); string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_LinkStyle(t *testing.T) {
+	const input = "[Docs](https://example.com/docs) and https://example.com and [top](#top)"
+
+	t.Run("auto", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithLinkStyle(LinkStyleAuto)}} {
+			paraContent := Convert(input, opts...)["content"].([]Node)[0]["content"].([]Node)
+			assertText(t, paraContent[0], "Docs")
+			assertMarks(t, paraContent[0], "link")
+			assertType(t, paraContent[2], "inlineCard")
+		}
+	})
+
+	t.Run("card", func(t *testing.T) {
+		paraContent := Convert(input, WithLinkStyle(LinkStyleCard))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 5 {
+			t.Fatalf("expected 5 nodes, got %d", len(paraContent))
+		}
+		assertType(t, paraContent[0], "inlineCard")
+		if url := paraContent[0]["attrs"].(Node)["url"]; url != "https://example.com/docs" {
+			t.Errorf("expected url 'https://example.com/docs', got %v", url)
+		}
+		assertType(t, paraContent[2], "inlineCard")
+		// Fragment links cannot become cards.
+		assertText(t, paraContent[4], "top")
+		assertMarks(t, paraContent[4], "link")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		paraContent := Convert("**see https://example.com** and DEV-1", WithLinkStyle(LinkStyleText), WithIssueKeyLinking("https://jira.example.com/browse/"))["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 4 {
+			t.Fatalf("expected 4 nodes, got %d: %v", len(paraContent), paraContent)
+		}
+		assertText(t, paraContent[1], "https://example.com")
+		assertMarks(t, paraContent[1], "link", "strong")
+		if href := paraContent[1]["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://example.com" {
+			t.Errorf("expected href 'https://example.com', got %v", href)
+		}
+		assertText(t, paraContent[3], "DEV-1")
+		assertMarks(t, paraContent[3], "link")
+		if len(collectNodes(Node{"content": paraContent}, "inlineCard")) != 0 {
+			t.Error("expected no inlineCard nodes")
+		}
+	})
+
+	t.Run("text keeps www label", func(t *testing.T) {
+		node := Convert("www.example.com", WithLinkStyle(LinkStyleText))["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, node, "www.example.com")
+		if href := node["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "http://www.example.com" {
+			t.Errorf("expected href 'http://www.example.com', got %v", href)
+		}
+	})
+}
+
+func TestConvert_CardInListItems(t *testing.T) {
+	input := "- https://a.example.com\n- <https://b.example.com/page>\n- https://c.example.com/x?y=1\n- see https://d.example.com"
+
+	for name, style := range map[string]LinkStyle{"card style": LinkStyleCard, "text style": LinkStyleText} {
+		t.Run(name, func(t *testing.T) {
+			items := Convert(input, WithCardInListItems(), WithLinkStyle(style))["content"].([]Node)[0]["content"].([]Node)
+			if len(items) != 4 {
+				t.Fatalf("expected 4 items, got %d", len(items))
+			}
+			urls := []string{"https://a.example.com", "https://b.example.com/page", "https://c.example.com/x?y=1"}
+			for i, url := range urls {
+				content := items[i]["content"].([]Node)
+				if len(content) != 1 {
+					t.Fatalf("item %d: expected 1 child, got %d", i, len(content))
+				}
+				assertType(t, content[0], "paragraph")
+				inline := content[0]["content"].([]Node)
+				if len(inline) != 1 {
+					t.Fatalf("item %d: expected 1 inline node, got %v", i, inline)
+				}
+				assertType(t, inline[0], "inlineCard")
+				if got := inline[0]["attrs"].(Node)["url"]; got != url {
+					t.Errorf("item %d: expected url %q, got %v", i, url, got)
+				}
+			}
+			if cards := collectNodes(items[3], "inlineCard"); style == LinkStyleText && len(cards) != 0 {
+				t.Errorf("expected item with other content unchanged, got %v", items[3])
+			}
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		items := Convert(input, WithLinkStyle(LinkStyleText))["content"].([]Node)[0]["content"].([]Node)
+		para := items[0]["content"].([]Node)[0]
+		assertType(t, para, "paragraph")
+		assertMarks(t, para["content"].([]Node)[0], "link")
+	})
+
+	t.Run("no-op with card style", func(t *testing.T) {
+		if with, without := stableJSON(t, Convert(input, WithCardInListItems())), stableJSON(t, Convert(input)); with != without {
+			t.Errorf("expected identical output, got\n%s\nwant\n%s", with, without)
+		}
+	})
+}
+
+func TestConvert_EmbedCardHosts(t *testing.T) {
+	input := "https://www.youtube.com/watch?v=abc\n\nhttps://example.com/page\n\nWatch https://youtube.com/watch?v=def\n\n- https://youtube.com/watch?v=ghi"
+	content := Convert(input, WithEmbedCardHosts([]string{"YouTube.com", "figma.com"}))["content"].([]Node)
+	if len(content) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(content))
+	}
+
+	assertType(t, content[0], "embedCard")
+	attrs := content[0]["attrs"].(Node)
+	if attrs["url"] != "https://www.youtube.com/watch?v=abc" {
+		t.Errorf("expected youtube url, got %v", attrs["url"])
+	}
+	if attrs["layout"] != "center" || attrs["width"] != 100 {
+		t.Errorf("expected centered full-width layout, got %v", attrs)
+	}
+
+	// Other hosts, URLs inside text, and nested blocks keep inline cards.
+	assertType(t, content[1], "paragraph")
+	assertType(t, content[1]["content"].([]Node)[0], "inlineCard")
+	assertType(t, content[2], "paragraph")
+	item := content[3]["content"].([]Node)[0]["content"].([]Node)[0]
+	assertType(t, item["content"].([]Node)[0], "inlineCard")
+
+	// Without the option, standalone URLs stay inline cards.
+	content = Convert(input)["content"].([]Node)
+	assertType(t, content[0]["content"].([]Node)[0], "inlineCard")
+}
+
 func TestConvert_ExplicitLink_StaysAsLink(t *testing.T) {
 	result := Convert("Click [this ticket](https://jira.example.com/browse/DEV-789)")
 	content := result["content"].([]Node)
@@ -621,6 +2206,120 @@ func TestConvert_BareEmail(t *testing.T) {
 	}
 }
 
+func TestConvert_BareEmailTrailingPunctuation(t *testing.T) {
+	tests := []struct {
+		input    string
+		email    string
+		trailing string
+	}{
+		{"mail me at a@b.com.", "a@b.com", "."},
+		{"ask support@example.com, please", "support@example.com", ", please"},
+		{"write to x@y.org!", "x@y.org", "!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := Convert(tt.input)
+			paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+			if len(paraContent) != 3 {
+				t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+			}
+			email := paraContent[1]
+			assertType(t, email, "text")
+			assertText(t, email, tt.email)
+			assertMarks(t, email, "link")
+			attrs := email["marks"].([]Node)[0]["attrs"].(Node)
+			if attrs["href"] != "mailto:"+tt.email {
+				t.Errorf("expected href %q, got %v", "mailto:"+tt.email, attrs["href"])
+			}
+			assertText(t, paraContent[2], tt.trailing)
+			assertMarks(t, paraContent[2])
+		})
+	}
+}
+
+func TestConvert_NonHTTPAutoLink(t *testing.T) {
+	tests := []struct {
+		markdown string
+		href     string
+	}{
+		{"Call <tel:+15551234> now", "tel:+15551234"},
+		{"Open <vscode://file/tmp/a.go> now", "vscode://file/tmp/a.go"},
+		{"Fetch <ftp://files.example.com/a.zip> now", "ftp://files.example.com/a.zip"},
+		{"Fetch ftp://files.example.com/a.zip now", "ftp://files.example.com/a.zip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 3 {
+				t.Fatalf("expected 3 nodes, got %d: %v", len(paraContent), paraContent)
+			}
+			link := paraContent[1]
+			assertText(t, link, tt.href)
+			assertMarks(t, link, "link")
+			if href := link["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != tt.href {
+				t.Errorf("expected href %q, got %v", tt.href, href)
+			}
+		})
+	}
+
+	t.Run("http stays a card", func(t *testing.T) {
+		paraContent := Convert("See <https://example.com> now")["content"].([]Node)[0]["content"].([]Node)
+		assertType(t, paraContent[1], "inlineCard")
+	})
+
+	t.Run("keeps surrounding marks", func(t *testing.T) {
+		paraContent := Convert("**<tel:+15551234>**")["content"].([]Node)[0]["content"].([]Node)
+		assertMarks(t, paraContent[0], "link", "strong")
+	})
+}
+
+func TestConvert_SmartLinkHosts(t *testing.T) {
+	opt := WithSmartLinkHosts([]string{"Atlassian.net", "github.com"})
+	tests := []struct {
+		markdown string
+		card     bool
+	}{
+		{"See https://acme.atlassian.net/browse/DEV-1 now", true},
+		{"See <https://github.com/codewandler/md2adf> now", true},
+		{"See https://example.com/page now", false},
+		{"See <https://notgithub.com/x> now", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			paraContent := Convert(tt.markdown, opt)["content"].([]Node)[0]["content"].([]Node)
+			if len(paraContent) != 3 {
+				t.Fatalf("expected 3 nodes, got %d: %v", len(paraContent), paraContent)
+			}
+			if tt.card {
+				assertType(t, paraContent[1], "inlineCard")
+				return
+			}
+			assertType(t, paraContent[1], "text")
+			assertMarks(t, paraContent[1], "link")
+		})
+	}
+
+	t.Run("card link style", func(t *testing.T) {
+		paraContent := Convert("[docs](https://example.com) and [repo](https://github.com/x)", opt, WithLinkStyle(LinkStyleCard))["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[0], "docs")
+		assertMarks(t, paraContent[0], "link")
+		assertType(t, paraContent[2], "inlineCard")
+	})
+
+	t.Run("issue keys", func(t *testing.T) {
+		paraContent := Convert("Fixes DEV-1", WithIssueKeyLinking("https://acme.example.com/browse/"), opt)["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[1], "DEV-1")
+		assertMarks(t, paraContent[1], "link")
+	})
+
+	t.Run("default allows all hosts", func(t *testing.T) {
+		paraContent := Convert("See https://example.com/page now")["content"].([]Node)[0]["content"].([]Node)
+		assertType(t, paraContent[1], "inlineCard")
+	})
+}
+
 func TestConvert_ExplicitMailtoLink(t *testing.T) {
 	result := Convert("[Email us](mailto:info@example.com)")
 	content := result["content"].([]Node)
@@ -659,10 +2358,348 @@ func TestConvert_EmptyInput(t *testing.T) {
 	if len(content) != 0 {
 		t.Errorf("expected 0 content nodes for empty input, got %d", len(content))
 	}
+	// ADF requires the content array, so it must not marshal as null.
+	if content == nil {
+		t.Error("expected empty, non-nil content")
+	}
+}
+
+func TestConvert_NoInlineAtTopLevel(t *testing.T) {
+	inputs := []string{
+		"plain text",
+		"https://example.com",
+		"DEV-1 and <https://example.com>\nline two  \nthree",
+		"![img](https://x.com/a.png) text ![b](https://x.com/b.png)",
+		"<sup>1</sup> <!-- note --> <span>raw</span>",
+		"<div>\nblock\n</div>\n\n<br>",
+		"[[Wiki Page]] {color:red}red{/color} ==mark== $x$",
+		"- [ ] task\n\n> [!NOTE]\n> alert\n\n| a |\n|---|\n| b |",
+	}
+	opts := []Option{
+		WithExternalMedia(), WithIssueKeyLinking("https://jira.example.com/browse/"),
+		WithWikiLinks(func(title string) (string, bool) { return "https://wiki.example.com/" + title, true }),
+		WithInlineMath(InlineMathText),
+		WithEmbedCardHosts([]string{"example.com"}),
+	}
+	for _, input := range inputs {
+		for _, content := range [][]Node{Convert(input)["content"].([]Node), Convert(input, opts...)["content"].([]Node), ConvertContent(input, opts...)} {
+			for _, node := range content {
+				if isInlineNode(node) {
+					t.Errorf("%q: inline %v node at top level", input, node["type"])
+				}
+			}
+		}
+	}
+}
+
+func TestWrapInline(t *testing.T) {
+	text := Node{"type": "text", "text": "stray"}
+	br := Node{"type": "hardBreak"}
+	para := Node{"type": "paragraph", "content": []Node{}}
+	card := Node{"type": "inlineCard", "attrs": Node{"url": "https://example.com"}}
+
+	got, _ := wrapInline([]Node{text, br, para, card}, nil)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 blocks, got %d: %v", len(got), got)
+	}
+	assertType(t, got[0], "paragraph")
+	if inline := got[0]["content"].([]Node); len(inline) != 2 || inline[0]["text"] != "stray" || inline[1]["type"] != "hardBreak" {
+		t.Errorf("expected text and hardBreak wrapped together, got %v", inline)
+	}
+	assertType(t, got[1], "paragraph")
+	assertType(t, got[2], "paragraph")
+	assertType(t, got[2]["content"].([]Node)[0], "inlineCard")
+}
+
+func TestConvert_PreserveEmptyParagraphs(t *testing.T) {
+	md := "First\n\n\nSecond\n\nThird"
+
+	content := Convert(md)["content"].([]Node)
+	if len(content) != 3 {
+		t.Fatalf("expected empty paragraphs dropped by default, got %d nodes", len(content))
+	}
+
+	content = Convert(md, WithPreserveEmptyParagraphs())["content"].([]Node)
+	if len(content) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(content))
+	}
+	assertText(t, content[0]["content"].([]Node)[0], "First")
+	assertType(t, content[1], "paragraph")
+	if _, ok := content[1]["content"]; ok {
+		t.Errorf("expected empty paragraph without content, got %v", content[1])
+	}
+	assertText(t, content[2]["content"].([]Node)[0], "Second")
+	assertText(t, content[3]["content"].([]Node)[0], "Third")
+
+	// Fence lines and blockquote markers are not blank lines.
+	md = "```\ncode\n```\n\n> a\n>\n>\n> b"
+	content = Convert(md, WithPreserveEmptyParagraphs())["content"].([]Node)
+	if len(content) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(content))
+	}
+	quote := content[1]["content"].([]Node)
+	if len(quote) != 3 || quote[1]["content"] != nil {
+		t.Errorf("expected spacer paragraph inside blockquote, got %v", quote)
+	}
+}
+
+func TestConvert_UnusedReferenceDefinition(t *testing.T) {
+	t.Run("alone", func(t *testing.T) {
+		for _, md := range []string{"[ref]: https://example.com", "[a]: https://a.com\n[b]: https://b.com"} {
+			if content := Convert(md)["content"].([]Node); len(content) != 0 {
+				t.Errorf("%q: expected no nodes, got %v", md, content)
+			}
+		}
+	})
+
+	t.Run("between paragraphs", func(t *testing.T) {
+		md := "Text\n\n[ref]: https://example.com\n\nMore"
+		for _, opts := range [][]Option{nil, {WithPreserveEmptyParagraphs()}} {
+			content := Convert(md, opts...)["content"].([]Node)
+			if len(content) != 2 {
+				t.Fatalf("expected 2 paragraphs, got %v", content)
+			}
+			assertText(t, content[0]["content"].([]Node)[0], "Text")
+			assertText(t, content[1]["content"].([]Node)[0], "More")
+		}
+	})
+
+	t.Run("in blockquote", func(t *testing.T) {
+		if content := Convert("> [ref]: https://example.com")["content"].([]Node); len(content) != 0 {
+			t.Errorf("expected empty blockquote dropped, got %v", content)
+		}
+	})
+}
+
+func TestConvertContent(t *testing.T) {
+	content := ConvertContent("# Title\n\nBody with **bold**.", WithHeadingOffset(1))
+	if len(content) != 2 {
+		t.Fatalf("expected 2 block nodes, got %d", len(content))
+	}
+	assertType(t, content[0], "heading")
+	if level := content[0]["attrs"].(Node)["level"]; level != 2 {
+		t.Errorf("expected level 2, got %v", level)
+	}
+	assertType(t, content[1], "paragraph")
+
+	doc := Convert("# Title\n\nBody with **bold**.", WithHeadingOffset(1))
+	want, _ := json.Marshal(doc["content"])
+	got, _ := json.Marshal(content)
+	if string(got) != string(want) {
+		t.Errorf("expected content to match Convert output\nwant: %s\ngot:  %s", want, got)
+	}
+}
+
+func TestConvert_WithoutVersion(t *testing.T) {
+	if version := Convert("text")["version"]; version != 1 {
+		t.Errorf("expected version 1 by default, got %v", version)
+	}
+
+	doc := Convert("# Title\n\ntext", WithoutVersion())
+	if _, ok := doc["version"]; ok {
+		t.Errorf("expected no version key, got %v", doc["version"])
+	}
+	assertType(t, doc, "doc")
+	if n := len(doc["content"].([]Node)); n != 2 {
+		t.Errorf("expected 2 content nodes, got %d", n)
+	}
+	if out, _ := json.Marshal(doc); strings.Contains(string(out), "version") {
+		t.Errorf("expected no version in JSON, got %s", out)
+	}
+}
+
+func TestConvertSafe(t *testing.T) {
+	doc, err := ConvertSafe("# Hello\n\nSome **bold** text.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertType(t, doc, "doc")
+	assertType(t, doc["content"].([]Node)[0], "heading")
+}
+
+func TestConvertSafe_PathologicalInput(t *testing.T) {
+	inputs := []string{
+		strings.Repeat(">", 2000) + " deep quote",
+		strings.Repeat("- ", 500) + "deep list",
+		strings.Repeat("[", 5000) + "x" + strings.Repeat("](u)", 5000),
+		strings.Repeat("*", 5000) + "x" + strings.Repeat("*", 5000),
+		strings.Repeat("| a ", 500) + "|\n" + strings.Repeat("| - ", 500) + "|\n" + strings.Repeat("| ", 10) + "|",
+		"\x00\xff\xfe<\x00>`\x00`",
+	}
+
+	for i, input := range inputs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic escaped ConvertSafe: %v", r)
+				}
+			}()
+			doc, err := ConvertSafe(input)
+			if err != nil {
+				return
+			}
+			assertType(t, doc, "doc")
+			if _, err := json.Marshal(doc); err != nil {
+				t.Errorf("failed to marshal result: %v", err)
+			}
+		})
+	}
+}
+
+func TestConvert_MaxDepth(t *testing.T) {
+	// depthOf returns the deepest nesting of content arrays below node.
+	var depthOf func(node Node) int
+	depthOf = func(node Node) int {
+		deepest := 0
+		children, _ := node["content"].([]Node)
+		for _, child := range children {
+			deepest = max(deepest, depthOf(child))
+		}
+		return deepest + 1
+	}
+
+	inputs := map[string]string{
+		"blockquotes": strings.Repeat("> ", 500) + "deep quote",
+		"lists":       strings.Repeat("- ", 500) + "deep list",
+		"emphasis":    strings.Repeat("*", 500) + "deep text" + strings.Repeat("*", 500),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			doc, diags := ConvertWithDiagnostics(input)
+			if _, err := json.Marshal(doc); err != nil {
+				t.Fatalf("failed to marshal result: %v", err)
+			}
+			// Lists nest two nodes (list and item) per level.
+			if d := depthOf(doc); d > 2*defaultMaxDepth+5 {
+				t.Errorf("expected output depth bounded by the default limit, got %d", d)
+			}
+			if len(diags) == 0 {
+				t.Error("expected a truncation diagnostic")
+			}
+		})
+	}
+
+	t.Run("custom limit", func(t *testing.T) {
+		input := strings.Repeat("> ", 5) + "deep"
+		doc := Convert(input, WithMaxDepth(3))
+		node := doc["content"].([]Node)[0]
+		for range 3 {
+			assertType(t, node, "blockquote")
+			node = node["content"].([]Node)[0]
+		}
+		assertType(t, node, "paragraph")
+		assertText(t, node["content"].([]Node)[0], "…")
+
+		if d := depthOf(Convert(input)); d != 8 {
+			t.Errorf("expected untruncated depth 8, got %d", d)
+		}
+	})
+
+	t.Run("inline keeps text", func(t *testing.T) {
+		para := Convert("**a *b `c` d* e**", WithMaxDepth(3))["content"].([]Node)[0]
+		if got := plainText(para["content"].([]Node)); got != "a b c d e" {
+			t.Errorf("expected text preserved, got %q", got)
+		}
+	})
+}
+
+func TestConvert_DeterministicOutput(t *testing.T) {
+	fragments := []string{
+		"# Heading", "## Sub *heading*", "plain text", "**bold**", "*italic*",
+		"~~strike~~", "`code`", "[link](https://example.com)", "https://bare.example.com",
+		"<https://auto.example.com>", "user@example.com", "![img](https://x.com/i.png)",
+		"- item", "1. first", "- [ ] task", "- [x] done @due(2024-01-01)", "> quote",
+		"---", "```go\nfunc main() {}\n```", "| a | b |\n| - | - |\n| 1 | 2 |",
+		"{color:#ff0000}red{/color}", "<kbd>Ctrl</kbd>", "line  \nbreak", "\\*escaped\\*",
+		"<table><tr><td>x</td></tr></table>",
+	}
+	separators := []string{" ", "\n", "\n\n", ""}
+
+	rng := rand.New(rand.NewPCG(1036, 1060))
+	for i := 0; i < 200; i++ {
+		var sb strings.Builder
+		for j := rng.IntN(12); j >= 0; j-- {
+			sb.WriteString(fragments[rng.IntN(len(fragments))])
+			sb.WriteString(separators[rng.IntN(len(separators))])
+		}
+		input := sb.String()
+
+		first := stableJSON(t, Convert(input))
+		for run := 0; run < 3; run++ {
+			if again := stableJSON(t, Convert(input)); again != first {
+				t.Fatalf("output not stable for input %q:\nfirst: %s\nagain: %s", input, first, again)
+			}
+		}
+	}
+}
+
+func BenchmarkConvertLargeDoc(b *testing.B) {
+	section := "## Section *heading*\n\n" +
+		"Some **bold** and *italic* text with `code`, a [link](https://example.com) and ~~strike~~.\n" +
+		"A second line with **bold *nested* text** and more plain words.\n\n" +
+		"**A bold paragraph\nwrapped over lines, with snake_case_words, [brackets] and\nwildcards * _ !**\n\n" +
+		"- item with **bold**\n- item with [link](https://example.com/path)\n\n" +
+		"| a | b |\n| - | - |\n| **1** | `2` |\n\n" +
+		"> quoted *text* here\n\n"
+	input := strings.Repeat(section, 500)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		Convert(input)
+	}
+}
+
+func BenchmarkConvertLargeTable(b *testing.B) {
+	const rows, cols = 100, 20
+	var sb strings.Builder
+	for row := -2; row < rows; row++ {
+		sb.WriteString("|")
+		for col := 0; col < cols; col++ {
+			switch row {
+			case -2:
+				fmt.Fprintf(&sb, " Column %d |", col)
+			case -1:
+				sb.WriteString(" --- |")
+			default:
+				fmt.Fprintf(&sb, " cell **%d** `%d` |", row, col)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	input := sb.String()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		Convert(input)
+	}
 }
 
 // Helper functions
 
+// stableJSON marshals node after blanking "localId" attrs, which are
+// randomly generated and therefore the only intentionally unstable values.
+func stableJSON(t *testing.T, node Node) string {
+	t.Helper()
+	var blank func(n Node)
+	blank = func(n Node) {
+		if attrs, ok := n["attrs"].(Node); ok {
+			if _, ok := attrs["localId"]; ok {
+				attrs["localId"] = ""
+			}
+		}
+		children, _ := n["content"].([]Node)
+		for _, child := range children {
+			blank(child)
+		}
+	}
+	blank(node)
+	out, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("failed to marshal result: %v", err)
+	}
+	return string(out)
+}
+
 func assertType(t *testing.T, node Node, expectedType string) {
 	t.Helper()
 	if node["type"] != expectedType {
@@ -676,3 +2713,158 @@ func assertText(t *testing.T, node Node, expectedText string) {
 		t.Errorf("expected text '%s', got '%v'", expectedText, node["text"])
 	}
 }
+
+func assertMarks(t *testing.T, node Node, expectedTypes ...string) {
+	t.Helper()
+	marks, _ := node["marks"].([]Node)
+	var got []string
+	for _, m := range marks {
+		got = append(got, fmt.Sprint(m["type"]))
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expectedTypes) {
+		t.Errorf("expected marks %v, got %v", expectedTypes, got)
+	}
+}
+
+// collectNodes returns every node of the given type in the tree rooted at
+// node, in depth-first order.
+func collectNodes(node Node, nodeType string) []Node {
+	var found []Node
+	if node["type"] == nodeType {
+		found = append(found, node)
+	}
+	children, _ := node["content"].([]Node)
+	for _, child := range children {
+		found = append(found, collectNodes(child, nodeType)...)
+	}
+	return found
+}
+
+func FuzzConvert(f *testing.F) {
+	seeds := []string{
+		"",
+		"# Hello\n\nSome **bold** and *italic* text.",
+		"- a\n  - b\n1. c\n\n5. d",
+		"- [ ] todo\n- [x] done @due(2024-01-01)",
+		"| a | b |\n|---|---|\n| https://example.com<br>• x | me@example.com |",
+		"> [!NOTE] Title\n> body",
+		"```mermaid\ngraph TD\n```\n\n    indented",
+		"<table><tr><td colspan=2>x</td></tr></table>",
+		"<!-- adf:ignore-start -->\nhidden\n<!-- adf:ignore-end -->",
+		"{color:#f00}red{/color} ==mark== [[Page]] $x$\n\n$$\ny\n$$",
+		"a. one\nb. two\n\n<> decided\n\n:::decision\nx\n:::",
+		"{align:center} ![img](a.png) ![b](b.png)\n\n&nbsp;",
+		"\x00\xff\xfe<\x00>`\x00`",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	opts := []Option{
+		WithInlineMath(InlineMathCode),
+		WithWikiLinks(func(title string) (string, bool) { return "https://wiki.example.com/" + title, true }),
+		WithAlphaLists(),
+		WithExternalMedia(),
+		WithCardInListItems(),
+		WithPreserveEmptyParagraphs(),
+		WithCommentDirectives(),
+		WithIssueKeyLinking("https://jira.example.com/browse/"),
+		WithDiagramMode(DiagramLink),
+		WithDiagramRenderURL("https://kroki.io"),
+		WithMaxDepth(20),
+		WithSourceMapping(),
+	}
+
+	f.Fuzz(func(t *testing.T, markdown string) {
+		for _, opts := range [][]Option{nil, opts} {
+			doc := Convert(markdown, opts...)
+			if doc["type"] != "doc" {
+				t.Fatalf("expected a doc node, got %v", doc["type"])
+			}
+			out, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("output does not marshal: %v", err)
+			}
+			if strings.Contains(string(out), `\u0000`) {
+				t.Fatalf("output contains a NUL character: %s", out)
+			}
+		}
+	})
+}
+
+func TestConvert_StripInvisibleChars(t *testing.T) {
+	input := "Re\u00adcon\u00adcil\u00adi\u00adation and zero\u200bwidth &shy;text\u2060 `co\u00adde\u200b`\n\n```\nblock\u00ad\u200b\n```"
+
+	t.Run("stripped", func(t *testing.T) {
+		content := Convert(input, WithStripInvisibleChars())["content"].([]Node)
+		inline := content[0]["content"].([]Node)
+		assertText(t, inline[0], "Reconciliation and zerowidth text ")
+		// Code spans and code blocks keep their characters.
+		assertText(t, inline[1], "co\u00adde\u200b")
+		assertMarks(t, inline[1], "code")
+		assertText(t, content[1]["content"].([]Node)[0], "block\u00ad\u200b")
+	})
+
+	t.Run("emoji sequences kept", func(t *testing.T) {
+		content := Convert("family \U0001F468\u200d\U0001F469\u200d\U0001F467", WithStripInvisibleChars())["content"].([]Node)
+		assertText(t, content[0]["content"].([]Node)[0], "family \U0001F468\u200d\U0001F469\u200d\U0001F467")
+	})
+
+	t.Run("default keeps them", func(t *testing.T) {
+		content := Convert("Re\u00adcon zero\u200bwidth")["content"].([]Node)
+		assertText(t, content[0]["content"].([]Node)[0], "Re\u00adcon zero\u200bwidth")
+	})
+}
+
+func TestConvert_CollapseWhitespace(t *testing.T) {
+	input := "a    b\t\tc  **d    e**  `x    y`  z\n\n```\nkeep    this\n```"
+
+	content := Convert(input, WithCollapseWhitespace())["content"].([]Node)
+	inline := content[0]["content"].([]Node)
+	if len(inline) != 5 {
+		t.Fatalf("expected 5 nodes, got %d: %v", len(inline), inline)
+	}
+	assertText(t, inline[0], "a b c ")
+	assertText(t, inline[1], "d e")
+	assertMarks(t, inline[1], "strong")
+	assertText(t, inline[2], " ")
+	// Code keeps its whitespace.
+	assertText(t, inline[3], "x    y")
+	assertText(t, inline[4], "  z")
+	assertText(t, content[1]["content"].([]Node)[0], "keep    this")
+
+	t.Run("non-breaking spaces kept", func(t *testing.T) {
+		inline := Convert("a \u00a0\u00a0 b", WithCollapseWhitespace())["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, inline[0], "a \u00a0\u00a0 b")
+	})
+
+	t.Run("default keeps spaces", func(t *testing.T) {
+		inline := Convert("a    b")["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, inline[0], "a    b")
+	})
+}
+
+func TestConvert_InlineCheckboxes(t *testing.T) {
+	const input = "Tested: [x] Linux [ ] macOS [X] Windows (and [ ]). Keep a[x], [x]y and `[x]`."
+
+	t.Run("enabled", func(t *testing.T) {
+		inline := Convert(input, WithInlineCheckboxes())["content"].([]Node)[0]["content"].([]Node)
+		if len(inline) != 3 {
+			t.Fatalf("expected 3 nodes, got %d: %v", len(inline), inline)
+		}
+		assertText(t, inline[0], "Tested: ☑ Linux ☐ macOS ☑ Windows (and ☐). Keep a[x], [x]y and ")
+		assertText(t, inline[1], "[x]")
+		assertMarks(t, inline[1], "code")
+	})
+
+	t.Run("default keeps brackets", func(t *testing.T) {
+		inline := Convert(input)["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, inline[0], "Tested: [x] Linux [ ] macOS [X] Windows (and [ ]). Keep a[x], [x]y and ")
+	})
+
+	t.Run("task lists unaffected", func(t *testing.T) {
+		content := Convert("- [x] done", WithInlineCheckboxes())["content"].([]Node)
+		assertType(t, content[0], "taskList")
+		item := content[0]["content"].([]Node)[0]
+		assertText(t, item["content"].([]Node)[0], "done")
+	})
+}
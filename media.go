@@ -0,0 +1,241 @@
+package md2adf
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// isMediaImage reports whether img should be rendered as an ADF "media" node
+// rather than the link fallback. This requires [WithExternalMedia], a
+// non-empty destination, inline or from a reference definition, and an
+// image that sits directly in a paragraph, so that the paragraph can be split
+// around it. The image may also be the sole content of a link in a
+// paragraph, as in [![alt](img)](href), in which case the media node gets the
+// link's mark. Images nested in emphasis or sharing a link with other
+// content keep the link fallback, as do images in task and decision items,
+// whose text is never split.
+func (c *converter) isMediaImage(img *ast.Image) bool {
+	if !c.cfg.externalMedia || c.normalizeHref(unescapeText(img.Destination)) == "" {
+		return false
+	}
+	parent := img.Parent()
+	if link, ok := parent.(*ast.Link); ok && link.ChildCount() == 1 {
+		parent = link.Parent()
+	}
+	switch parent.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		return c.splitsParagraph(parent)
+	}
+	return false
+}
+
+// splitsParagraph reports whether the paragraph or text block para is
+// converted by [converter.convertChildren], which splits it around media.
+// Task and decision items hold inline content only, so their text is
+// converted without splitting.
+func (c *converter) splitsParagraph(para ast.Node) bool {
+	switch parent := para.Parent().(type) {
+	case *decisionList:
+		return false
+	case *ast.ListItem:
+		list, ok := parent.Parent().(*ast.List)
+		return !ok || c.cfg.taskListAsText || !isTaskList(list)
+	}
+	return true
+}
+
+// mediaNode returns an external "media" node for img.
+func (c *converter) mediaNode(img *ast.Image) Node {
+	attrs := Node{
+		"type": "external",
+		"url":  c.normalizeHref(unescapeText(img.Destination)),
+	}
+	if alt := sanitizeText(string(img.Text(c.source))); alt != "" {
+		attrs["alt"] = alt
+	}
+	if c.cfg.imageDimensions {
+		if width, height, ok := imageDimensions(string(img.Title)); ok {
+			attrs["width"] = width
+			attrs["height"] = height
+		}
+	}
+	return Node{"type": "media", "attrs": attrs}
+}
+
+// hasLinkedMedia reports whether any of the media nodes carries a link mark.
+func hasLinkedMedia(media []Node) bool {
+	for _, node := range media {
+		if marks, _ := node["marks"].([]Node); hasMark(marks, "link") {
+			return true
+		}
+	}
+	return false
+}
+
+// imageSizePattern matches an image size token such as "=200x100".
+var imageSizePattern = regexp.MustCompile(`^=([1-9][0-9]*)[xX]([1-9][0-9]*)$`)
+
+// imageDimensions returns the size given by the first well-formed
+// "=WIDTHxHEIGHT" word of an image title.
+func imageDimensions(title string) (width, height int, ok bool) {
+	for _, word := range strings.Fields(title) {
+		m := imageSizePattern.FindStringSubmatch(word)
+		if m == nil {
+			continue
+		}
+		width, werr := strconv.Atoi(m[1])
+		height, herr := strconv.Atoi(m[2])
+		if werr == nil && herr == nil {
+			return width, height, true
+		}
+	}
+	return 0, 0, false
+}
+
+// splitMedia splits the converted paragraph para around its "media" nodes
+// with [splitMediaParagraph]. The paragraph's marks, such as alignment or
+// indentation, are copied onto every paragraph it splits into. Paragraphs
+// without media are returned unchanged.
+func (c *converter) splitMedia(para Node) []Node {
+	content, _ := para["content"].([]Node)
+	if !slices.ContainsFunc(content, func(node Node) bool { return node["type"] == "media" }) {
+		return []Node{para}
+	}
+	blocks := splitMediaParagraph(content, c.cfg.target != TargetConfluence)
+	if marks, ok := para["marks"].([]Node); ok {
+		for _, block := range blocks {
+			if block["type"] == "paragraph" {
+				block["marks"] = copyMarks(marks)
+			}
+		}
+	}
+	return blocks
+}
+
+// splitMediaParagraph turns the inline content of a paragraph into block
+// nodes by lifting out "media" nodes, which ADF only allows inside block
+// containers. A single image becomes a "mediaSingle"; a run of adjacent
+// images, separated by nothing but whitespace, becomes a "mediaGroup". The
+// remaining inline content before, between, and after the runs is wrapped in
+// paragraphs. Without group, every image of a run gets its own
+// "mediaSingle", as does a run holding a linked image, since ADF only allows
+// link marks on media in a "mediaSingle".
+func splitMediaParagraph(content []Node, group bool) []Node {
+	var blocks, inline, run []Node
+
+	flushInline := func() {
+		if !isBlankInline(inline) {
+			blocks = append(blocks, Node{"type": "paragraph", "content": trimInline(inline)})
+		}
+		inline = nil
+	}
+	flushRun := func() {
+		switch {
+		case len(run) == 0:
+			return
+		case len(run) == 1 || !group || hasLinkedMedia(run):
+			for _, media := range run {
+				blocks = append(blocks, Node{
+					"type":    "mediaSingle",
+					"attrs":   Node{"layout": "center"},
+					"content": []Node{media},
+				})
+			}
+		default:
+			blocks = append(blocks, Node{"type": "mediaGroup", "content": run})
+		}
+		run = nil
+	}
+
+	for _, node := range content {
+		if node["type"] == "media" {
+			if len(run) == 0 {
+				flushInline()
+			}
+			inline = nil // whitespace between images of a run is dropped
+			run = append(run, node)
+			continue
+		}
+		if len(run) > 0 && isBlankInline([]Node{node}) {
+			inline = append(inline, node)
+			continue
+		}
+		flushRun()
+		inline = append(inline, node)
+	}
+	flushRun()
+	flushInline()
+	return blocks
+}
+
+// isBlankInline reports whether nodes hold nothing but whitespace text and
+// line breaks.
+func isBlankInline(nodes []Node) bool {
+	for _, node := range nodes {
+		switch node["type"] {
+		case "hardBreak":
+		case "text":
+			if strings.TrimSpace(node["text"].(string)) != "" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// trimInline removes leading and trailing line breaks and whitespace left
+// over at the edges of a paragraph after media was lifted out.
+func trimInline(nodes []Node) []Node {
+	for len(nodes) > 0 && isBlankInline(nodes[:1]) {
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && isBlankInline(nodes[len(nodes)-1:]) {
+		nodes = nodes[:len(nodes)-1]
+	}
+	// Only store changed text: boxing a string into the node allocates.
+	if len(nodes) > 0 && nodes[0]["type"] == "text" {
+		text := nodes[0]["text"].(string)
+		if trimmed := strings.TrimLeft(text, " \t\n"); trimmed != text {
+			nodes[0]["text"] = trimmed
+		}
+	}
+	if last := len(nodes) - 1; last >= 0 && nodes[last]["type"] == "text" {
+		text := nodes[last]["text"].(string)
+		if trimmed := strings.TrimRight(text, " \t\n"); trimmed != text {
+			nodes[last]["text"] = trimmed
+		}
+	}
+	return nodes
+}
+
+// imageLabel is the link text of images without alt text under
+// [AltFallbackLabel].
+const imageLabel = "image"
+
+// altFallback returns the link text for an image without alt text pointing
+// at href, as selected by [WithAltFallback].
+func (c *converter) altFallback(href string) string {
+	switch c.cfg.altFallback {
+	case AltFallbackLabel:
+		return imageLabel
+	case AltFallbackFilename:
+		u, err := url.Parse(href)
+		if err != nil {
+			return imageLabel
+		}
+		name := path.Base(u.Path)
+		if name == "." || name == "/" {
+			return imageLabel
+		}
+		return name
+	}
+	return href
+}
@@ -0,0 +1,313 @@
+package md2adf
+
+import "testing"
+
+func TestConvert_ExternalMediaSingle(t *testing.T) {
+	result := Convert("![diagram](https://x.com/d.png)", WithExternalMedia())
+	content := result["content"].([]Node)
+	if len(content) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(content))
+	}
+	single := content[0]
+	assertType(t, single, "mediaSingle")
+
+	media := single["content"].([]Node)
+	if len(media) != 1 {
+		t.Fatalf("expected 1 media node, got %d", len(media))
+	}
+	assertType(t, media[0], "media")
+	attrs := media[0]["attrs"].(Node)
+	if attrs["type"] != "external" || attrs["url"] != "https://x.com/d.png" || attrs["alt"] != "diagram" {
+		t.Errorf("unexpected media attrs %v", attrs)
+	}
+}
+
+func TestConvert_ExternalMediaGroup(t *testing.T) {
+	input := "Screenshots:\n![one](https://x.com/1.png) ![two](https://x.com/2.png)\n![three](https://x.com/3.png)\nThat's all."
+	result := Convert(input, WithExternalMedia())
+	content := result["content"].([]Node)
+
+	if len(content) != 3 {
+		t.Fatalf("expected paragraph, mediaGroup, paragraph; got %d nodes", len(content))
+	}
+	assertType(t, content[0], "paragraph")
+	assertText(t, content[0]["content"].([]Node)[0], "Screenshots:")
+
+	group := content[1]
+	assertType(t, group, "mediaGroup")
+	media := group["content"].([]Node)
+	if len(media) != 3 {
+		t.Fatalf("expected 3 media nodes, got %d", len(media))
+	}
+	for i, m := range media {
+		assertType(t, m, "media")
+		want := "https://x.com/" + string(rune('1'+i)) + ".png"
+		if url := m["attrs"].(Node)["url"]; url != want {
+			t.Errorf("media %d: expected url %q, got %v", i, want, url)
+		}
+	}
+
+	assertType(t, content[2], "paragraph")
+	assertText(t, content[2]["content"].([]Node)[0], "That's all.")
+}
+
+func TestConvert_ExternalMediaInListItem(t *testing.T) {
+	result := Convert("- ![a](https://x.com/a.png)", WithExternalMedia())
+	item := result["content"].([]Node)[0]["content"].([]Node)[0]
+	assertType(t, item["content"].([]Node)[0], "mediaSingle")
+}
+
+func TestConvert_ExternalMediaNestedKeepsLink(t *testing.T) {
+	result := Convert("**![a](https://x.com/a.png)**", WithExternalMedia())
+	para := result["content"].([]Node)[0]
+	assertType(t, para, "paragraph")
+	node := para["content"].([]Node)[0]
+	assertText(t, node, "a")
+	assertMarks(t, node, "link", "strong")
+}
+
+func TestConvert_ExternalMediaParagraphOptions(t *testing.T) {
+	// Paragraphs without images convert the same with external media.
+	tests := []struct {
+		name     string
+		markdown string
+		opts     []Option
+	}{
+		{"alignment", "{align:center} hello", nil},
+		{"indentation", "{indent:2} hello", nil},
+		{"nbsp spacer", "a\n\n&nbsp;\n\nb", []Option{WithNbspSpacers()}},
+		{"preserved empty paragraph", "a\n\n<span></span>\n\nb", []Option{WithPreserveEmptyParagraphs()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := stableJSON(t, Convert(tt.markdown, tt.opts...))
+			got := stableJSON(t, Convert(tt.markdown, append(tt.opts, WithExternalMedia())...))
+			if got != want {
+				t.Errorf("expected output unchanged by WithExternalMedia\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+
+	t.Run("split paragraphs keep marks", func(t *testing.T) {
+		content := Convert("{align:center} before ![a](https://x.com/a.png) after", WithExternalMedia())["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(content))
+		}
+		assertType(t, content[1], "mediaSingle")
+		for _, i := range []int{0, 2} {
+			assertMarks(t, content[i], "alignment")
+		}
+	})
+}
+
+func TestConvert_ExternalMediaInlineOnlyItemsKeepLink(t *testing.T) {
+	// Task and decision items hold inline content only, so a media node
+	// cannot be split out of them.
+	tests := []struct {
+		markdown string
+		itemType string
+	}{
+		{"- [ ] see ![x](https://x.com/a.png)", "taskItem"},
+		{"<> see ![x](https://x.com/a.png)", "decisionItem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.itemType, func(t *testing.T) {
+			result := Convert(tt.markdown, WithExternalMedia())
+			if media := collectNodes(result, "media"); len(media) != 0 {
+				t.Fatalf("expected no media nodes, got %v", media)
+			}
+			item := result["content"].([]Node)[0]["content"].([]Node)[0]
+			assertType(t, item, tt.itemType)
+			content := item["content"].([]Node)
+			if len(content) != 2 {
+				t.Fatalf("expected 2 inline nodes, got %v", content)
+			}
+			assertText(t, content[1], "x")
+			assertMarks(t, content[1], "link")
+		})
+	}
+
+	// Rendered as a regular list, the item can hold the media.
+	result := Convert("- [ ] see ![x](https://x.com/a.png)", WithExternalMedia(), WithTaskListAsText())
+	if media := collectNodes(result, "mediaSingle"); len(media) != 1 {
+		t.Errorf("expected 1 mediaSingle, got %d", len(media))
+	}
+}
+
+func TestConvert_ExternalMediaReference(t *testing.T) {
+	t.Run("resolved", func(t *testing.T) {
+		result := Convert("![diagram][d]\n\n[d]: https://x.com/d.png", WithExternalMedia())
+		content := result["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		assertType(t, content[0], "mediaSingle")
+		attrs := content[0]["content"].([]Node)[0]["attrs"].(Node)
+		if attrs["url"] != "https://x.com/d.png" || attrs["alt"] != "diagram" {
+			t.Errorf("unexpected media attrs %v", attrs)
+		}
+	})
+
+	t.Run("collapsed without media", func(t *testing.T) {
+		node := Convert("![diagram][]\n\n[diagram]: https://x.com/d.png")["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, node, "diagram")
+		assertMarks(t, node, "link")
+		if href := node["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://x.com/d.png" {
+			t.Errorf("expected href https://x.com/d.png, got %v", href)
+		}
+	})
+
+	t.Run("empty destination", func(t *testing.T) {
+		for _, opts := range [][]Option{nil, {WithExternalMedia()}} {
+			content := Convert("![diagram][d]\n\n[d]: <>", opts...)["content"].([]Node)
+			if len(content) != 1 {
+				t.Fatalf("expected 1 node, got %d", len(content))
+			}
+			assertType(t, content[0], "paragraph")
+			node := content[0]["content"].([]Node)[0]
+			assertText(t, node, "diagram")
+			assertMarks(t, node)
+		}
+	})
+}
+
+func TestConvert_AltFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		fallback AltFallback
+		markdown string
+		want     string
+	}{
+		{"url by default", AltFallbackURL, "![](https://x.com/img/diagram.png)", "https://x.com/img/diagram.png"},
+		{"label", AltFallbackLabel, "![](https://x.com/img/diagram.png)", "image"},
+		{"filename", AltFallbackFilename, "![](https://x.com/img/my%20diagram.png?v=2#top)", "my diagram.png"},
+		{"relative filename", AltFallbackFilename, "![](assets/logo.svg)", "logo.svg"},
+		{"no filename", AltFallbackFilename, "![](https://x.com/)", "image"},
+		{"alt wins", AltFallbackFilename, "![Logo](assets/logo.svg)", "Logo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			para := Convert(tt.markdown, WithAltFallback(tt.fallback))["content"].([]Node)[0]
+			node := para["content"].([]Node)[0]
+			assertText(t, node, tt.want)
+			assertMarks(t, node, "link")
+		})
+	}
+}
+
+func TestConvert_ImageDimensions(t *testing.T) {
+	mediaAttrs := func(t *testing.T, md string, opts ...Option) Node {
+		t.Helper()
+		single := Convert(md, append([]Option{WithExternalMedia()}, opts...)...)["content"].([]Node)[0]
+		assertType(t, single, "mediaSingle")
+		return single["content"].([]Node)[0]["attrs"].(Node)
+	}
+
+	tests := []struct {
+		markdown      string
+		width, height any
+	}{
+		{`![logo](https://x.com/logo.png "=200x100")`, 200, 100},
+		{`![logo](https://x.com/logo.png "Company logo =64X32")`, 64, 32},
+		{`![logo][l]` + "\n\n" + `[l]: https://x.com/logo.png "=10x20"`, 10, 20},
+		{`![logo](https://x.com/logo.png "=200")`, nil, nil},
+		{`![logo](https://x.com/logo.png "=0x100")`, nil, nil},
+		{`![logo](https://x.com/logo.png "=200x100px")`, nil, nil},
+		{`![logo](https://x.com/logo.png "200x100")`, nil, nil},
+		{`![logo](https://x.com/logo.png "=axb")`, nil, nil},
+		{`![logo](https://x.com/logo.png)`, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.markdown, func(t *testing.T) {
+			attrs := mediaAttrs(t, tt.markdown, WithImageDimensions())
+			if attrs["width"] != tt.width || attrs["height"] != tt.height {
+				t.Errorf("expected %vx%v, got %vx%v", tt.width, tt.height, attrs["width"], attrs["height"])
+			}
+			if attrs["url"] == "" || attrs["alt"] != "logo" {
+				t.Errorf("unexpected media attrs %v", attrs)
+			}
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		attrs := mediaAttrs(t, `![logo](https://x.com/logo.png "=200x100")`)
+		if _, ok := attrs["width"]; ok {
+			t.Errorf("expected no width, got %v", attrs)
+		}
+	})
+}
+
+func TestConvert_ExternalMediaConfluence(t *testing.T) {
+	input := "![one](https://x.com/1.png) ![two](https://x.com/2.png)\nDone."
+	content := Convert(input, WithExternalMedia(), WithTarget(TargetConfluence))["content"].([]Node)
+
+	if len(content) != 3 {
+		t.Fatalf("expected 2 mediaSingle nodes and a paragraph, got %d nodes", len(content))
+	}
+	for i, node := range content[:2] {
+		assertType(t, node, "mediaSingle")
+		media := node["content"].([]Node)
+		if len(media) != 1 {
+			t.Fatalf("mediaSingle %d: expected 1 media node, got %d", i, len(media))
+		}
+		want := "https://x.com/" + string(rune('1'+i)) + ".png"
+		if url := media[0]["attrs"].(Node)["url"]; url != want {
+			t.Errorf("media %d: expected url %q, got %v", i, want, url)
+		}
+	}
+	assertText(t, content[2]["content"].([]Node)[0], "Done.")
+}
+
+func TestConvert_LinkedImage(t *testing.T) {
+	const input = "[![logo](https://x.com/logo.png)](https://example.com/home)"
+
+	t.Run("text fallback", func(t *testing.T) {
+		paraContent := Convert(input)["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "logo")
+		assertMarks(t, paraContent[0], "link")
+		if href := paraContent[0]["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://example.com/home" {
+			t.Errorf("expected outer href, got %v", href)
+		}
+	})
+
+	t.Run("media", func(t *testing.T) {
+		content := Convert(input, WithExternalMedia())["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		assertType(t, content[0], "mediaSingle")
+		media := content[0]["content"].([]Node)[0]
+		assertType(t, media, "media")
+		if url := media["attrs"].(Node)["url"]; url != "https://x.com/logo.png" {
+			t.Errorf("expected image url, got %v", url)
+		}
+		assertMarks(t, media, "link")
+		if href := media["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://example.com/home" {
+			t.Errorf("expected outer href, got %v", href)
+		}
+	})
+
+	t.Run("not grouped", func(t *testing.T) {
+		content := Convert(input+" ![b](https://x.com/b.png)", WithExternalMedia())["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected 2 mediaSingle nodes, got %d", len(content))
+		}
+		assertType(t, content[0], "mediaSingle")
+		assertType(t, content[1], "mediaSingle")
+		if _, ok := content[1]["content"].([]Node)[0]["marks"]; ok {
+			t.Error("expected unlinked image without marks")
+		}
+	})
+
+	t.Run("link with more text", func(t *testing.T) {
+		paraContent := Convert("[![logo](https://x.com/logo.png) Home](https://example.com/home)", WithExternalMedia())["content"].([]Node)[0]["content"].([]Node)
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "logo Home")
+		assertMarks(t, paraContent[0], "link")
+	})
+}
@@ -0,0 +1,657 @@
+package md2adf
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Option configures optional behaviour of [Convert]. Options are applied in
+// the order they are given; later options override earlier ones.
+type Option func(*config)
+
+// config holds the resolved conversion settings. The zero value reproduces
+// the default behaviour of [Convert] without any options.
+type config struct {
+	// headingOffset is added to every heading level before clamping to 1-6.
+	headingOffset int
+	// issueBaseURL enables issue key linking when non-empty.
+	issueBaseURL string
+	// inlineMath enables $math$ parsing when non-zero.
+	inlineMath InlineMathMode
+	// baseURL resolves relative link destinations when non-nil.
+	baseURL *url.URL
+	// kbdAsCode renders <kbd> content with a code mark.
+	kbdAsCode bool
+	// taskMetadata receives annotations stripped from task items.
+	taskMetadata func(text string, annotations []TaskAnnotation)
+	// frontmatter selects how a leading frontmatter block is handled.
+	frontmatter FrontmatterMode
+	// unknownAsText keeps unsupported blocks as raw source text.
+	unknownAsText bool
+	// softBreak selects how soft line breaks are rendered.
+	softBreak SoftBreakMode
+	// singleTildeStrike accepts ~text~ as strikethrough besides ~~text~~.
+	singleTildeStrike bool
+	// plainHeadings strips formatting marks from heading content.
+	plainHeadings bool
+	// externalMedia renders images as external media nodes.
+	externalMedia bool
+	// imageDimensions reads "=WxH" size tokens from media image titles.
+	imageDimensions bool
+	// altFallback selects the link text of images without alt text.
+	altFallback AltFallback
+	// cardInListItems renders bare-URL list items as inline cards.
+	cardInListItems bool
+	// embedCardHosts lists lowercase hosts whose bare URLs become embed cards.
+	embedCardHosts []string
+	// preserveEmptyParagraphs emits empty paragraphs instead of dropping them.
+	preserveEmptyParagraphs bool
+	// nbspSpacers keeps blank paragraphs holding a non-breaking space.
+	nbspSpacers bool
+	// tableLayout is the table layout attr; empty means "default".
+	tableLayout string
+	// tableNumberColumn enables the automatic row-number column on tables.
+	tableNumberColumn bool
+	// tablesAsList flattens tables into bullet lists of "Header: value" pairs.
+	tablesAsList bool
+	// wikiLinks resolves [[Page Name]] titles to URLs when non-nil.
+	wikiLinks func(title string) (url string, ok bool)
+	// diagramMode selects how Mermaid and PlantUML code blocks are rendered.
+	diagramMode DiagramMode
+	// diagramRenderURL is the rendering service used by DiagramLink.
+	diagramRenderURL string
+	// highlightColor is the ==highlight== background; empty means yellow.
+	highlightColor string
+	// alphaLists parses a./i. style list markers as ordered lists.
+	alphaLists bool
+	// maxDepth bounds nesting; zero or less means defaultMaxDepth.
+	maxDepth int
+	// commentDirectives enables <!-- adf:... --> comment directives.
+	commentDirectives bool
+	// linkStyle selects between inlineCard and text link rendering.
+	linkStyle LinkStyle
+	// localIDGenerator replaces the random UUIDs used for localId attrs.
+	localIDGenerator func() string
+	// omitVersion leaves the "version" key out of the doc node.
+	omitVersion bool
+	// sourceMapping annotates top-level nodes with their source lines.
+	sourceMapping bool
+	// stripInvisibleChars removes soft hyphens and zero-width characters.
+	stripInvisibleChars bool
+	// target selects the Atlassian product the output is tuned for.
+	target Target
+	// smartLinkHosts lists lowercase hosts whose URLs may become cards; nil
+	// allows all hosts.
+	smartLinkHosts []string
+	// collapseWhitespace collapses runs of spaces and tabs in text.
+	collapseWhitespace bool
+	// inlineCheckboxes turns "[ ]" and "[x]" in text into ballot boxes.
+	inlineCheckboxes bool
+	// taskListAsText renders task lists as regular lists with ballot boxes.
+	taskListAsText bool
+	// unicodeEmoji turns known Unicode emoji in text into emoji nodes.
+	unicodeEmoji bool
+}
+
+// defaultMaxDepth is the nesting limit used without [WithMaxDepth].
+const defaultMaxDepth = 100
+
+// WithHeadingOffset shifts every heading level by n. The result is clamped
+// to the ADF-valid range 1-6, so with an offset of 1 a "# Title" becomes a
+// level 2 heading and a "###### Deep" heading stays at level 6. Negative
+// offsets promote headings and clamp at level 1.
+//
+// This is useful when embedding converted Markdown below an existing
+// heading in a Jira or Confluence page.
+func WithHeadingOffset(n int) Option {
+	return func(cfg *config) {
+		cfg.headingOffset = n
+	}
+}
+
+// WithSingleTildeStrike accepts "~text~" as strikethrough in addition to
+// "~~text~~", as GitHub does. By default only double tildes strike through
+// text and single tildes stay literal, since a single tilde commonly means
+// "approximately" ("~5 minutes"). Tilde runs of three or more are never
+// strikethrough.
+//
+// This is a breaking change: earlier versions struck through "~text~" by
+// default. Pass this option to keep that behavior.
+func WithSingleTildeStrike() Option {
+	return func(cfg *config) {
+		cfg.singleTildeStrike = true
+	}
+}
+
+// WithPlainHeadings removes formatting marks (strong, em, code, strike, and
+// so on) from heading text while keeping the text itself and any links.
+//
+// The ADF schema accepts all marks inside headings, and Jira and Confluence
+// render strong, em, strike, and link there. Some consumers, however, ignore
+// or reject code and color marks in headings; enable this option when
+// targeting such consumers.
+func WithPlainHeadings() Option {
+	return func(cfg *config) {
+		cfg.plainHeadings = true
+	}
+}
+
+// WithIssueKeyLinking turns bare Jira issue keys such as "DEV-123" into
+// "inlineCard" nodes whose url is baseURL followed by the key, e.g.
+// "https://example.atlassian.net/browse/" + "DEV-123". Keys inside code spans,
+// code blocks, and link text are left untouched.
+func WithIssueKeyLinking(baseURL string) Option {
+	return func(cfg *config) {
+		cfg.issueBaseURL = baseURL
+	}
+}
+
+// WithBaseURL resolves relative link and image destinations such as
+// "/docs/setup" or "../README.md" against base, producing absolute URLs that
+// Jira and Confluence can follow. Without a base URL, or if base cannot be
+// parsed as an absolute URL, relative destinations are kept as written.
+func WithBaseURL(base string) Option {
+	return func(cfg *config) {
+		u, err := url.Parse(base)
+		if err != nil || !u.IsAbs() {
+			cfg.baseURL = nil
+			return
+		}
+		cfg.baseURL = u
+	}
+}
+
+// InlineMathMode selects how $...$ math spans are rendered by
+// [WithInlineMath]. ADF has no math node, so every mode is a fallback.
+type InlineMathMode int
+
+const (
+	// InlineMathCode renders the math source as text with a "code" mark.
+	InlineMathCode InlineMathMode = iota + 1
+	// InlineMathText renders the math source as plain text without the
+	// surrounding dollar signs.
+	InlineMathText
+	// InlineMathDrop removes inline math from the output entirely.
+	InlineMathDrop
+)
+
+// WithInlineMath enables parsing of $...$ inline math and $$...$$ display
+// math. Inline spans are rendered according to mode. Display math on its own
+// lines always becomes a "codeBlock" with language "latex".
+//
+// Without this option dollar signs are treated as ordinary text.
+func WithInlineMath(mode InlineMathMode) Option {
+	return func(cfg *config) {
+		cfg.inlineMath = mode
+	}
+}
+
+// WithKbdAsCode renders text wrapped in inline <kbd>...</kbd> HTML tags with a
+// "code" mark, the closest ADF equivalent to keyboard key styling. The tags
+// themselves are removed, so "<kbd>Ctrl</kbd>+<kbd>C</kbd>" produces two
+// code-marked runs separated by a literal "+". Without this option the tags
+// are dropped like any other raw HTML and the key names render as plain text.
+func WithKbdAsCode() Option {
+	return func(cfg *config) {
+		cfg.kbdAsCode = true
+	}
+}
+
+// WithTaskMetadata registers fn to receive the trailing "@name(value)"
+// annotations, such as "@due(2024-01-01)" or "@owner(alice)", that are
+// stripped from task list items. fn is called once per annotated task with
+// the task's remaining plain text and its annotations in source order.
+//
+// Annotations are stripped whether or not this option is set; a "@due" date
+// is always rendered as an ADF "date" node.
+func WithTaskMetadata(fn func(text string, annotations []TaskAnnotation)) Option {
+	return func(cfg *config) {
+		cfg.taskMetadata = fn
+	}
+}
+
+// FrontmatterMode selects how [WithFrontmatter] handles a frontmatter block
+// at the start of the Markdown.
+type FrontmatterMode int
+
+const (
+	// FrontmatterKeep converts frontmatter like any other Markdown, which
+	// usually yields a rule followed by a paragraph or heading. This is the
+	// default.
+	FrontmatterKeep FrontmatterMode = iota
+	// FrontmatterStrip removes the frontmatter block before conversion.
+	FrontmatterStrip
+)
+
+// WithFrontmatter controls the handling of a frontmatter block as written
+// by static site generators: YAML between "---" lines or TOML between
+// "+++" lines at the very start of the Markdown. An unclosed block is not
+// frontmatter. Diagnostics keep reporting the lines of the original
+// source. Use [ConvertFileWithFrontmatter] to read the frontmatter keys.
+func WithFrontmatter(mode FrontmatterMode) Option {
+	return func(cfg *config) {
+		cfg.frontmatter = mode
+	}
+}
+
+// WithUnknownAsText emits block types the converter does not explicitly
+// handle as a paragraph holding their original Markdown source, one hard
+// break per source line, so that no content is lost. By default such blocks
+// are reduced to their first converted child, or dropped if they have none.
+func WithUnknownAsText() Option {
+	return func(cfg *config) {
+		cfg.unknownAsText = true
+	}
+}
+
+// SoftBreakMode selects how soft line breaks, i.e. single newlines inside a
+// paragraph, are rendered by [WithSoftBreak].
+type SoftBreakMode int
+
+const (
+	// SoftBreakSpace renders a soft break as a single space. This is the
+	// default and matches how browsers render Markdown.
+	SoftBreakSpace SoftBreakMode = iota
+	// SoftBreakHardBreak renders a soft break as an ADF "hardBreak" node,
+	// preserving the source line structure.
+	SoftBreakHardBreak
+	// SoftBreakJoin drops soft breaks entirely, concatenating the lines.
+	SoftBreakJoin
+)
+
+// WithSoftBreak controls how soft line breaks are rendered. Use
+// [SoftBreakHardBreak] for content such as addresses or poetry where the
+// line structure is meaningful.
+func WithSoftBreak(mode SoftBreakMode) Option {
+	return func(cfg *config) {
+		cfg.softBreak = mode
+	}
+}
+
+// WithExternalMedia renders images that sit directly in a paragraph as ADF
+// media nodes of type "external" instead of the default link fallback. A
+// single image becomes a "mediaSingle" block and a run of adjacent images a
+// "mediaGroup" block; any text around them is kept in separate paragraphs.
+// Images inside links, emphasis, headings, or table cells keep the link
+// fallback, as ADF only allows media in block positions.
+func WithExternalMedia() Option {
+	return func(cfg *config) {
+		cfg.externalMedia = true
+	}
+}
+
+// AltFallback selects the link text used by [WithAltFallback] for images
+// without alt text.
+type AltFallback int
+
+const (
+	// AltFallbackURL uses the image URL as link text. This is the default.
+	AltFallbackURL AltFallback = iota
+	// AltFallbackLabel uses the fixed label "image".
+	AltFallbackLabel
+	// AltFallbackFilename uses the last segment of the URL path, such as
+	// "diagram.png" for "https://example.com/img/diagram.png?v=2". URLs
+	// without a file name fall back to the label "image".
+	AltFallbackFilename
+)
+
+// WithAltFallback controls the link text of images with empty alt text,
+// such as "![](diagram.png)", which are rendered as links because ADF has no
+// inline images. Images rendered as media by [WithExternalMedia] are not
+// affected.
+func WithAltFallback(fallback AltFallback) Option {
+	return func(cfg *config) {
+		cfg.altFallback = fallback
+	}
+}
+
+// WithImageDimensions reads an image size encoded in the title, as in
+// ![logo](logo.png "=200x100"), and sets the "width" and "height" attrs of
+// the "media" node. The size token may sit among other words of the title;
+// titles without a well-formed WIDTHxHEIGHT token in pixels are left alone.
+// It only affects images rendered as media by [WithExternalMedia].
+func WithImageDimensions() Option {
+	return func(cfg *config) {
+		cfg.imageDimensions = true
+	}
+}
+
+// WithCardInListItems only matters with [LinkStyleText]: it renders list
+// items whose sole content is a bare URL as a paragraph holding an
+// "inlineCard" smart link, as the default [LinkStyleCard] already does. This
+// suits documents that end with a list of reference URLs. ADF does not
+// allow a "blockCard" inside a "listItem", so the card stays inline. Items
+// with any other content are unchanged.
+func WithCardInListItems() Option {
+	return func(cfg *config) {
+		cfg.cardInListItems = true
+	}
+}
+
+// WithEmbedCardHosts turns top-level paragraphs holding only a bare URL on
+// one of the given hosts, or one of their subdomains, into block-level
+// "embedCard" nodes, which Confluence renders as an embedded player or
+// preview. Listing "youtube.com" matches "https://www.youtube.com/watch?v=…"
+// but not "https://notyoutube.com". Other URLs stay "inlineCard" nodes.
+func WithEmbedCardHosts(hosts []string) Option {
+	return func(cfg *config) {
+		cfg.embedCardHosts = nil
+		for _, host := range hosts {
+			cfg.embedCardHosts = append(cfg.embedCardHosts, strings.ToLower(host))
+		}
+	}
+}
+
+// WithPreserveEmptyParagraphs keeps blank paragraphs as empty ADF
+// paragraphs ({"type":"paragraph"}) instead of dropping them. Besides
+// paragraphs whose content converts to nothing, every blank line beyond the
+// first between two blocks yields one empty paragraph, so "a\n\n\nb"
+// becomes paragraph, empty paragraph, paragraph. Some ADF consumers use
+// empty paragraphs as intentional vertical spacing.
+func WithPreserveEmptyParagraphs() Option {
+	return func(cfg *config) {
+		cfg.preserveEmptyParagraphs = true
+	}
+}
+
+// WithNbspSpacers keeps paragraphs that hold only whitespace including a
+// non-breaking space, such as a lone "&nbsp;" line used as a vertical
+// spacer. Other whitespace-only paragraphs are always dropped like empty
+// ones.
+func WithNbspSpacers() Option {
+	return func(cfg *config) {
+		cfg.nbspSpacers = true
+	}
+}
+
+// WithTableLayout sets the "layout" attribute of every converted table.
+// Valid values are "default", "wide", "full-width", and "center"; wide
+// tables usually render best with "full-width". Any other value falls back
+// to "default".
+func WithTableLayout(layout string) Option {
+	return func(cfg *config) {
+		switch layout {
+		case "default", "wide", "full-width", "center":
+			cfg.tableLayout = layout
+		default:
+			cfg.tableLayout = ""
+		}
+	}
+}
+
+// WithTableNumberColumn sets the "isNumberColumnEnabled" attribute of every
+// converted table, making Jira and Confluence show an automatic row-number
+// column. The renderer adds the numbers itself, so rows keep the same cells.
+func WithTableNumberColumn(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.tableNumberColumn = enabled
+	}
+}
+
+// WithTablesAsList flattens GFM tables into bullet lists, which read better
+// than wide tables on narrow displays such as Jira on mobile. Each data row
+// becomes a list item with one "Header: value" paragraph per non-empty cell;
+// empty cells are left out, and a table without data keeps its header labels
+// as a single item. The conversion is lossy: column alignment and
+// any block structure within cells are not preserved.
+func WithTablesAsList() Option {
+	return func(cfg *config) {
+		cfg.tablesAsList = true
+	}
+}
+
+// WithWikiLinks enables Confluence-style [[Page Name]] wiki links. Each
+// title is passed to resolve; when it reports ok, the title is rendered as
+// text with a link mark pointing at the returned URL (resolved against
+// [WithBaseURL] if relative). Unresolvable titles are kept as plain text
+// without the brackets. To link every page below a common base instead,
+// have resolve build the URL from the title and always report ok.
+func WithWikiLinks(resolve func(title string) (url string, ok bool)) Option {
+	return func(cfg *config) {
+		cfg.wikiLinks = resolve
+	}
+}
+
+// DiagramMode selects how fenced "mermaid" and "plantuml" code blocks are
+// rendered by [WithDiagramMode]. Jira and Confluence cannot render these
+// diagrams natively.
+type DiagramMode int
+
+const (
+	// DiagramCode keeps diagrams as ordinary code blocks. This is the
+	// default.
+	DiagramCode DiagramMode = iota
+	// DiagramPanel wraps the diagram source in an info panel noting that
+	// the diagram was not rendered.
+	DiagramPanel
+	// DiagramLink replaces the diagram with a paragraph linking to a
+	// rendering of it on the service configured via [WithDiagramRenderURL].
+	// Without a service, diagrams stay code blocks.
+	DiagramLink
+)
+
+// WithDiagramMode controls how fenced code blocks with language "mermaid"
+// or "plantuml" are rendered. See [DiagramMode] for the available modes.
+func WithDiagramMode(mode DiagramMode) Option {
+	return func(cfg *config) {
+		cfg.diagramMode = mode
+	}
+}
+
+// WithDiagramRenderURL sets the base URL of a Kroki-compatible rendering
+// service used by [DiagramLink]. Diagrams link to
+// base/<language>/svg/<encoded source>, where the source is deflated and
+// base64url encoded as the Kroki GET API expects.
+func WithDiagramRenderURL(base string) Option {
+	return func(cfg *config) {
+		cfg.diagramRenderURL = strings.TrimRight(base, "/")
+	}
+}
+
+// WithHighlightColor sets the "backgroundColor" mark color used for
+// ==highlighted== text, as a 3 or 6 digit hex color such as "#e3fcef".
+// Invalid colors keep the default yellow #fff0b3.
+func WithHighlightColor(color string) Option {
+	return func(cfg *config) {
+		cfg.highlightColor, _ = normalizeHexColor(color)
+	}
+}
+
+// WithAlphaLists recognizes paragraphs starting with alpha ("a.", "B)") or
+// roman ("i.", "IV)") list markers, which CommonMark treats as plain text,
+// and converts them to "orderedList" nodes. Each line starting with a marker
+// begins a new item, and items separated by blank lines stay in one list.
+//
+// ADF ordered lists have no numbering style, so Jira and Confluence render
+// these lists with digits. Items can hold only inline text: indented blocks
+// below an item, such as nested lists, are not attached to it.
+func WithAlphaLists() Option {
+	return func(cfg *config) {
+		cfg.alphaLists = true
+	}
+}
+
+// WithMaxDepth limits how deeply nested blocks (blockquotes, lists) and
+// inline formatting are converted, guarding against pathological input.
+// Block content below n levels is replaced by a paragraph holding "…", and
+// deeper inline formatting is flattened to its plain text. Each truncation
+// is reported as a [Diagnostic]. Values of n below 1 select the default
+// limit of 100.
+func WithMaxDepth(n int) Option {
+	return func(cfg *config) {
+		cfg.maxDepth = n
+	}
+}
+
+// WithCommentDirectives enables HTML comment directives. Content between
+// <!-- adf:ignore-start --> and <!-- adf:ignore-end --> is left out of the
+// output, which suits internal notes that should not reach Jira. The
+// directives work between blocks and within a paragraph; a missing end
+// directive ignores the rest of the enclosing block, such as the list item
+// or blockquote, or the rest of the document at the top level.
+func WithCommentDirectives() Option {
+	return func(cfg *config) {
+		cfg.commentDirectives = true
+	}
+}
+
+// LinkStyle selects how links are rendered by [WithLinkStyle].
+type LinkStyle int
+
+const (
+	// LinkStyleAuto renders explicit [text](url) links as text with a
+	// "link" mark and bare URLs, autolinks, and issue keys as
+	// "inlineCard" nodes. This is the default.
+	LinkStyleAuto LinkStyle = iota
+	// LinkStyleCard renders every link to an absolute http or https URL as
+	// an "inlineCard" (a smart link), dropping the display text of
+	// explicit links. Other links keep the text style.
+	LinkStyleCard
+	// LinkStyleText renders every link, including bare URLs, autolinks,
+	// and issue keys, as text with a "link" mark, so no "inlineCard" nodes
+	// are produced.
+	LinkStyleText
+)
+
+// WithLinkStyle controls whether links become "inlineCard" smart links or
+// text with a "link" mark. See [LinkStyle] for the available styles.
+func WithLinkStyle(style LinkStyle) Option {
+	return func(cfg *config) {
+		cfg.linkStyle = style
+	}
+}
+
+// WithLocalIDGenerator sets the function that produces "localId" attrs for
+// nodes that need one, such as "taskItem" and "decisionItem". It is called once
+// per node in document order. By default each ID is a random UUIDv4;
+// supply a counter to get reproducible output, for example in tests.
+func WithLocalIDGenerator(generate func() string) Option {
+	return func(cfg *config) {
+		cfg.localIDGenerator = generate
+	}
+}
+
+// WithoutVersion leaves the "version" key out of the doc node returned by
+// [Convert], for callers that embed the doc in an envelope of their own
+// that carries the version. To get the block nodes without any doc
+// wrapper, use [ConvertContent] instead.
+func WithoutVersion() Option {
+	return func(cfg *config) {
+		cfg.omitVersion = true
+	}
+}
+
+// WithSourceMapping annotates every top-level node with the 1-based range of
+// source lines it was converted from, under the [SourceLineKey] and
+// [SourceEndLineKey] keys. This helps map problems reported for a converted
+// document, such as a rejected Jira import, back to the Markdown. The keys
+// are not valid ADF: remove them with [StripSourceMapping] before sending
+// the document. Lines are counted from the start of the input, including
+// any frontmatter stripped by [WithFrontmatter].
+func WithSourceMapping() Option {
+	return func(cfg *config) {
+		cfg.sourceMapping = true
+	}
+}
+
+// WithStripInvisibleChars removes soft hyphens (U+00AD) and zero-width
+// spaces, word joiners and byte order marks (U+200B, U+2060, U+FEFF) from
+// text, as found in text copied from PDFs. Jira renders some of them as
+// visible characters and they break searching. Zero-width joiners and
+// non-joiners are kept, as emoji sequences and some scripts depend on them.
+// Code spans and code blocks are left untouched.
+func WithStripInvisibleChars() Option {
+	return func(cfg *config) {
+		cfg.stripInvisibleChars = true
+	}
+}
+
+// Target is the Atlassian product whose dialect of ADF [Convert] produces.
+// Both products accept the same basic nodes but differ in a few details.
+type Target int
+
+const (
+	// TargetJira produces ADF for Jira issue fields and comments. This is
+	// the default.
+	TargetJira Target = iota
+	// TargetConfluence produces ADF for Confluence pages. GitHub "[!TIP]"
+	// alerts become "tip" panels, which Jira does not offer, instead of
+	// "success" panels, and adjacent images with [WithExternalMedia] each
+	// get their own "mediaSingle", as Confluence renders a "mediaGroup" as
+	// a strip of file cards rather than images.
+	TargetConfluence
+)
+
+// WithTarget tunes the output for the given Atlassian product. See
+// [Target] for the differences.
+func WithTarget(target Target) Option {
+	return func(cfg *config) {
+		cfg.target = target
+	}
+}
+
+// WithSmartLinkHosts limits "inlineCard" smart links to URLs on the given
+// hosts, or one of their subdomains, such as the Atlassian site and the
+// services the Jira instance has smart link integrations for. Bare URLs and
+// autolinks on other hosts become text with a "link" mark, since cards that
+// cannot be resolved show as raw URLs. This also applies to [LinkStyleCard]
+// and issue keys, so include the host of [WithIssueKeyLinking]'s base URL,
+// and to the bare URLs turned into embed cards by [WithEmbedCardHosts].
+// Without this option, or with a nil list, every http and https URL may
+// become a card.
+func WithSmartLinkHosts(hosts []string) Option {
+	return func(cfg *config) {
+		cfg.smartLinkHosts = nil
+		if hosts != nil {
+			cfg.smartLinkHosts = []string{}
+		}
+		for _, host := range hosts {
+			cfg.smartLinkHosts = append(cfg.smartLinkHosts, strings.ToLower(host))
+		}
+	}
+}
+
+// WithCollapseWhitespace collapses runs of spaces and tabs in text to a
+// single space, as HTML renderers display them, since Jira shows them
+// literally. Non-breaking spaces, code spans, and code blocks are left
+// untouched.
+func WithCollapseWhitespace() Option {
+	return func(cfg *config) {
+		cfg.collapseWhitespace = true
+	}
+}
+
+// WithInlineCheckboxes replaces checkboxes written in running text, such as
+// "Tested: [x] Linux [ ] macOS", with the ballot box symbols "☐" and "☑".
+// Only brackets standing alone are replaced, so array indexes like "a[x]"
+// and link text stay literal; checkboxes in code are left untouched, and
+// task list items become ADF task lists regardless of this option. Without
+// it, inline checkboxes are kept as literal text.
+func WithInlineCheckboxes() Option {
+	return func(cfg *config) {
+		cfg.inlineCheckboxes = true
+	}
+}
+
+// WithTaskListAsText renders task lists as regular bullet or ordered lists
+// whose items start with a "☐" or "☑" ballot box instead of ADF "taskList"
+// nodes, for Jira fields that do not accept task lists. Checkboxes of lists
+// that could not become a task list anyway, which are otherwise kept as
+// literal "[ ] " text, get the ballot boxes too. Task annotations such as
+// "@due(2024-01-01)" are kept as text.
+func WithTaskListAsText() Option {
+	return func(cfg *config) {
+		cfg.taskListAsText = true
+	}
+}
+
+// WithUnicodeEmoji turns common Unicode emoji in text, such as "👍" or "🎉",
+// into ADF "emoji" nodes with their short name, e.g. ":thumbsup:", which
+// Jira renders in its own emoji style. Emoji outside the built-in table,
+// emoji sequences such as those with skin tones, and emoji in code or link
+// text stay plain text, as do all emoji without this option.
+func WithUnicodeEmoji() Option {
+	return func(cfg *config) {
+		cfg.unicodeEmoji = true
+	}
+}
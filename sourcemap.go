@@ -0,0 +1,159 @@
+package md2adf
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// Keys of the source line annotations added by [WithSourceMapping]. They
+// are not part of the ADF schema and must be removed with
+// [StripSourceMapping] before the document is sent to Jira or Confluence.
+const (
+	SourceLineKey    = "_sourceLine"
+	SourceEndLineKey = "_sourceEndLine"
+)
+
+// StripSourceMapping removes the annotations added by [WithSourceMapping]
+// from the top-level nodes of doc in place and returns doc.
+func StripSourceMapping(doc Node) Node {
+	content, _ := doc["content"].([]Node)
+	for _, node := range content {
+		delete(node, SourceLineKey)
+		delete(node, SourceEndLineKey)
+	}
+	return doc
+}
+
+// annotateSource records the 1-based source lines of src on node for
+// [WithSourceMapping]. Nodes without a known position are left alone.
+func (c *converter) annotateSource(node Node, src ast.Node) {
+	if start, end, ok := c.sourceLines(src); ok {
+		node[SourceLineKey] = start
+		node[SourceEndLineKey] = end
+	}
+}
+
+// sourceLines returns the approximate 1-based range of source lines that
+// the block n was parsed from.
+func (c *converter) sourceLines(n ast.Node) (start, end int, ok bool) {
+	if start, end, ok := c.recordedLines(n); ok {
+		return start, end, true
+	}
+
+	// goldmark records no position for thematic breaks and blocks without
+	// content; they start on the first non-blank line after the previous
+	// block. Walk back to the nearest sibling whose last line is known and
+	// locate the blocks after it in order, remembering where each one ends
+	// so that long runs of such blocks are only scanned once.
+	pending := []ast.Node{n}
+	line := 1
+	for prev := n.PreviousSibling(); prev != nil; prev = prev.PreviousSibling() {
+		if prevEnd, known := c.blockEnds[prev]; known {
+			line = prevEnd + 1
+			break
+		}
+		if _, prevEnd, known := c.recordedLines(prev); known {
+			line = prevEnd + 1
+			break
+		}
+		pending = append(pending, prev)
+	}
+	if c.blockEnds == nil {
+		c.blockEnds = map[ast.Node]int{}
+	}
+	for i := len(pending) - 1; i >= 0; i-- {
+		if start, end, ok = c.locateBlock(pending[i], line); !ok {
+			return 0, 0, false
+		}
+		c.blockEnds[pending[i]] = end
+		line = end + 1
+	}
+	return start, end, true
+}
+
+// recordedLines returns the source lines of n from the positions goldmark
+// recorded for it or its descendants.
+func (c *converter) recordedLines(n ast.Node) (start, end int, ok bool) {
+	first, stop, ok := c.nodeRange(n)
+	if !ok {
+		return 0, 0, false
+	}
+	start = c.lineAt(first)
+	// The last line's newline belongs to that line.
+	end = c.lineAt(max(stop-1, first))
+	if fenced, isFenced := n.(*ast.FencedCodeBlock); isFenced {
+		// goldmark only records the code lines; the opening fence is the
+		// line before them, or the line holding the info string.
+		start--
+		if fenced.Info != nil {
+			start = c.lineAt(fenced.Info.Segment.Start)
+		}
+		if isCodeFence(c.line(end + 1)) {
+			end++
+		}
+	}
+	return start, end, true
+}
+
+// locateBlock returns the source lines of the block n without a recorded
+// position, which starts on the first non-blank line from line on.
+func (c *converter) locateBlock(n ast.Node, line int) (start, end int, ok bool) {
+	for offset := c.lineOffset(line); offset < len(c.source); offset = c.lineOffset(line) {
+		if !util.IsBlank(c.line(line)) {
+			if _, isFenced := n.(*ast.FencedCodeBlock); isFenced && isCodeFence(c.line(line+1)) {
+				return line, line + 1, true
+			}
+			return line, line, true
+		}
+		line++
+	}
+	return 0, 0, false
+}
+
+// lineStarts returns the byte offset at which each source line starts,
+// computed on first use.
+func (c *converter) lineStarts() []int {
+	if c.lineStartOffsets == nil {
+		c.lineStartOffsets = []int{0}
+		for i, b := range c.source {
+			if b == '\n' {
+				c.lineStartOffsets = append(c.lineStartOffsets, i+1)
+			}
+		}
+	}
+	return c.lineStartOffsets
+}
+
+// lineAt returns the 1-based source line holding the byte at offset.
+func (c *converter) lineAt(offset int) int {
+	line, _ := slices.BinarySearch(c.lineStarts(), offset+1)
+	return line
+}
+
+// lineOffset returns the byte offset at which the 1-based source line
+// starts, or the length of the source if there is no such line.
+func (c *converter) lineOffset(line int) int {
+	starts := c.lineStarts()
+	if line > len(starts) {
+		return len(c.source)
+	}
+	return starts[max(line, 1)-1]
+}
+
+// line returns the text of the 1-based source line, without its newline.
+func (c *converter) line(line int) []byte {
+	rest := c.source[c.lineOffset(line):]
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+// isCodeFence reports whether line is a code fence of backticks or tildes.
+func isCodeFence(line []byte) bool {
+	line = util.TrimLeftSpace(line)
+	return bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~"))
+}
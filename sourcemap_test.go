@@ -0,0 +1,98 @@
+package md2adf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConvert_SourceMapping(t *testing.T) {
+	input := "Intro\n\n## Heading\n\nA paragraph\nover two lines.\n\n```go\ncode\n```\n\n- one\n- two\n\n---\n"
+	content := Convert(input, WithSourceMapping())["content"].([]Node)
+
+	want := []struct {
+		nodeType   string
+		start, end int
+	}{
+		{"paragraph", 1, 1},
+		{"heading", 3, 3},
+		{"paragraph", 5, 6},
+		{"codeBlock", 8, 10},
+		{"bulletList", 12, 13},
+		{"rule", 15, 15},
+	}
+	if len(content) != len(want) {
+		t.Fatalf("expected %d nodes, got %d", len(want), len(content))
+	}
+	for i, w := range want {
+		assertType(t, content[i], w.nodeType)
+		if start, end := content[i][SourceLineKey], content[i][SourceEndLineKey]; start != w.start || end != w.end {
+			t.Errorf("%s: expected lines %d-%d, got %v-%v", w.nodeType, w.start, w.end, start, end)
+		}
+	}
+
+	// Only top-level nodes are annotated.
+	item := content[4]["content"].([]Node)[0]
+	if _, ok := item[SourceLineKey]; ok {
+		t.Errorf("expected nested nodes without source lines, got %v", item)
+	}
+}
+
+func TestConvert_SourceMappingWithoutPositions(t *testing.T) {
+	// goldmark records no position for thematic breaks or empty blocks.
+	content := Convert("```\ncode\n```\n***\n\n---\n\n```\n```\n", WithSourceMapping())["content"].([]Node)
+	want := [][2]int{{1, 3}, {4, 4}, {6, 6}, {8, 9}}
+	if len(content) != len(want) {
+		t.Fatalf("expected %d nodes, got %d", len(want), len(content))
+	}
+	for i, w := range want {
+		if start, end := content[i][SourceLineKey], content[i][SourceEndLineKey]; start != w[0] || end != w[1] {
+			t.Errorf("node %d: expected lines %d-%d, got %v-%v", i, w[0], w[1], start, end)
+		}
+	}
+}
+
+func TestConvert_SourceMappingManyBreaks(t *testing.T) {
+	// Runs of blocks without positions are located in one pass; this used
+	// to take over a minute.
+	const n = 20000
+	content := Convert(strings.Repeat("---\n\n", n)+"end", WithSourceMapping())["content"].([]Node)
+	if len(content) != n+1 {
+		t.Fatalf("expected %d nodes, got %d", n+1, len(content))
+	}
+	for _, i := range []int{0, 1, n - 1, n} {
+		if line := content[i][SourceLineKey]; line != 2*i+1 {
+			t.Errorf("node %d: expected line %d, got %v", i, 2*i+1, line)
+		}
+	}
+}
+
+func TestConvert_SourceMappingDisabled(t *testing.T) {
+	heading := Convert("# Title")["content"].([]Node)[0]
+	if _, ok := heading[SourceLineKey]; ok {
+		t.Errorf("expected no source lines by default, got %v", heading)
+	}
+}
+
+func TestConvert_SourceMappingMedia(t *testing.T) {
+	content := Convert("text\n\nBefore ![a](https://x.com/a.png) after", WithSourceMapping(), WithExternalMedia())["content"].([]Node)
+	if len(content) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(content))
+	}
+	for i, node := range content[1:] {
+		if line := node[SourceLineKey]; line != 3 {
+			t.Errorf("node %d: expected line 3, got %v", i+1, line)
+		}
+	}
+}
+
+func TestStripSourceMapping(t *testing.T) {
+	doc := StripSourceMapping(Convert("# Title\n\ntext", WithSourceMapping()))
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "_source") {
+		t.Errorf("expected source lines to be stripped, got %s", out)
+	}
+}
@@ -0,0 +1,54 @@
+package md2adf
+
+// Stats summarizes what a conversion produced, for reporting on migrated
+// content. It is returned by [ConvertWithStats].
+type Stats struct {
+	// Blocks counts the block-level ADF nodes by type, such as "heading",
+	// "table", "codeBlock", or "panel", at any nesting depth. Structural
+	// children of other blocks, such as "listItem" or "tableRow", are not
+	// counted.
+	Blocks map[string]int
+	// Links counts links rendered as text with a "link" mark, including
+	// email and wiki links. A link spanning several formatted text nodes
+	// counts once.
+	Links int
+	// Cards counts links rendered as smart links, such as bare URLs and
+	// issue keys. This includes cards promoted to an "embedCard", which
+	// Blocks counts as well.
+	Cards int
+	// Images counts images, whether rendered as media or as links.
+	Images int
+	// DroppedHTML counts the raw HTML blocks and inline tags that were
+	// skipped. Comments are not counted.
+	DroppedHTML int
+}
+
+// ConvertWithStats is like [Convert] but additionally returns [Stats] about
+// the converted content. The counts are collected during conversion, so
+// this costs no extra pass over the document.
+func ConvertWithStats(markdown string, opts ...Option) (Node, Stats) {
+	c := newConverter([]byte(markdown), opts)
+	c.stats = &Stats{Blocks: map[string]int{}}
+	doc := c.convertDocument()
+	return doc, *c.stats
+}
+
+// record applies update to the collected stats if stats are being
+// collected.
+func (c *converter) record(update func(s *Stats)) {
+	if c.stats != nil {
+		update(c.stats)
+	}
+}
+
+// recordBlocks counts the given block nodes by type.
+func (c *converter) recordBlocks(nodes []Node) {
+	if c.stats == nil {
+		return
+	}
+	for _, node := range nodes {
+		if nodeType, ok := node["type"].(string); ok {
+			c.stats.Blocks[nodeType]++
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package md2adf
+
+import "testing"
+
+func TestConvertWithStats(t *testing.T) {
+	md := "# Title\n\n" +
+		"Intro with a [link](https://example.com/a), **[bold link](https://example.com/b)**, and me@example.com.\n\n" +
+		"See https://example.com/c and ![logo](logo.png).\n\n" +
+		"## Details\n\n" +
+		"- one\n- two\n  <span>x</span>\n\n" +
+		"```go\nfmt.Println()\n```\n\n" +
+		"| a | b |\n|---|---|\n| https://example.com/d | 2 |\n\n" +
+		"<div>dropped</div>\n\n" +
+		"<!-- comment -->\n\n" +
+		"> [!NOTE]\n> Heads up.\n\n" +
+		"---\n"
+
+	doc, stats := ConvertWithStats(md)
+	assertType(t, doc, "doc")
+
+	wantBlocks := map[string]int{
+		"heading":    2,
+		"paragraph":  9, // intro, see, two list items, four table cells, panel body
+		"bulletList": 1,
+		"codeBlock":  1,
+		"table":      1,
+		"panel":      1,
+		"rule":       1,
+	}
+	for nodeType, want := range wantBlocks {
+		if got := stats.Blocks[nodeType]; got != want {
+			t.Errorf("expected %d %s blocks, got %d", want, nodeType, got)
+		}
+	}
+	if len(stats.Blocks) != len(wantBlocks) {
+		t.Errorf("unexpected block types: %v", stats.Blocks)
+	}
+	if stats.Links != 3 {
+		t.Errorf("expected 3 links, got %d", stats.Links)
+	}
+	if stats.Cards != 2 {
+		t.Errorf("expected 2 cards, got %d", stats.Cards)
+	}
+	if stats.Images != 1 {
+		t.Errorf("expected 1 image, got %d", stats.Images)
+	}
+	if stats.DroppedHTML != 3 {
+		t.Errorf("expected 3 dropped HTML pieces, got %d", stats.DroppedHTML)
+	}
+
+	// The document matches a plain conversion.
+	if got, want := stableJSON(t, doc), stableJSON(t, Convert(md)); got != want {
+		t.Errorf("expected the same document as Convert\ngot:  %s\nwant: %s", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package md2adf
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// tildeStrikeParser wraps goldmark's strikethrough parser, which accepts
+// both "~text~" and "~~text~~", to accept single tildes only when
+// singleTilde is set. Rejected single tildes stay literal text, so "~5
+// minutes ~ tops" is not struck through by accident. goldmark's own
+// extension, used by earlier versions, struck through single tildes by
+// default; [WithSingleTildeStrike] restores that.
+type tildeStrikeParser struct {
+	parser.InlineParser
+	singleTilde bool
+}
+
+func (p *tildeStrikeParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !p.singleTilde && (len(line) < 2 || line[1] != '~') {
+		return nil
+	}
+	return p.InlineParser.Parse(parent, block, pc)
+}
+
+// strikethroughExtension registers [tildeStrikeParser] in place of
+// goldmark's [extension.Strikethrough], at the same priority.
+type strikethroughExtension struct {
+	singleTilde bool
+}
+
+func (e strikethroughExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(
+			util.Prioritized(&tildeStrikeParser{extension.NewStrikethroughParser(), e.singleTilde}, 500),
+		),
+	)
+}
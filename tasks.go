@@ -0,0 +1,210 @@
+package md2adf
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// TaskAnnotation is a trailing "@name(value)" annotation, such as
+// "@due(2024-01-01)" or "@owner(alice)", that was stripped from the text of a
+// task list item.
+type TaskAnnotation struct {
+	Name  string
+	Value string
+}
+
+// annotationSpace holds the whitespace allowed around task annotations.
+const annotationSpace = " \t\n\f\r"
+
+// trailingTaskAnnotation parses the @name(value) annotation at the end of
+// text, ignoring surrounding whitespace. The "@" must start text or follow
+// whitespace, so that email-like text such as "bob@owner(x)" is kept. The
+// name consists of ASCII letters, digits, and underscores; the value may not
+// contain parentheses. start is
+// the offset in text at which the annotation and the whitespace before it
+// begin.
+func trailingTaskAnnotation(text string) (annotation TaskAnnotation, start int, ok bool) {
+	s := strings.TrimRight(text, annotationSpace)
+	if !strings.HasSuffix(s, ")") {
+		return TaskAnnotation{}, 0, false
+	}
+	open := strings.LastIndexAny(s[:len(s)-1], "()")
+	if open < 0 || s[open] != '(' {
+		return TaskAnnotation{}, 0, false
+	}
+	at := open
+	for at > 0 && isAnnotationNameByte(s[at-1]) {
+		at--
+	}
+	if at == open || at == 0 || s[at-1] != '@' || (at > 1 && !strings.ContainsRune(annotationSpace, rune(s[at-2]))) {
+		return TaskAnnotation{}, 0, false
+	}
+	annotation = TaskAnnotation{Name: s[at:open], Value: s[open+1 : len(s)-1]}
+	return annotation, len(strings.TrimRight(s[:at-1], annotationSpace)), true
+}
+
+// isAnnotationNameByte reports whether b may appear in an annotation name.
+func isAnnotationNameByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// isTaskList reports whether every item of list starts with a GFM task
+// checkbox and contains nothing that an ADF "taskItem" cannot represent, i.e.
+// only paragraphs and nested task lists. Lists that fail this check are
+// converted as regular lists with the checkboxes kept as literal text.
+func isTaskList(list *ast.List) bool {
+	if !list.HasChildren() {
+		return false
+	}
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		if _, ok := item.(*ast.ListItem); !ok {
+			return false
+		}
+		if taskCheckBox(item) == nil {
+			return false
+		}
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			switch block := child.(type) {
+			case *ast.Paragraph, *ast.TextBlock:
+			case *ast.List:
+				if !isTaskList(block) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// taskCheckBox returns the checkbox that opens a list item, or nil if the item
+// is not a task item.
+func taskCheckBox(item ast.Node) *extast.TaskCheckBox {
+	first := item.FirstChild()
+	if first == nil {
+		return nil
+	}
+	checkBox, _ := first.FirstChild().(*extast.TaskCheckBox)
+	return checkBox
+}
+
+// convertTaskList converts a list accepted by [isTaskList] into an ADF
+// "taskList". ADF task items hold inline content only, so additional
+// paragraphs of an item are joined with hard breaks, and nested task lists
+// are emitted as "taskList" siblings directly after their parent item.
+func (c *converter) convertTaskList(list *ast.List) Node {
+	// IDs are taken before converting children so they follow document order.
+	listID := c.localID()
+	var content []Node
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		itemID := c.localID()
+		var inline, nested []Node
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			if sub, ok := child.(*ast.List); ok {
+				if c.descend(sub) {
+					nested = append(nested, c.convertTaskList(sub))
+					c.ascend()
+				}
+				continue
+			}
+			paragraph := c.convertInlineChildren(child, nil)
+			if len(inline) > 0 && len(paragraph) > 0 {
+				inline = append(inline, Node{"type": "hardBreak"})
+			}
+			inline = append(inline, paragraph...)
+		}
+
+		state := "TODO"
+		if taskCheckBox(item).IsChecked {
+			state = "DONE"
+		}
+		taskItem := Node{
+			"type":  "taskItem",
+			"attrs": Node{"localId": itemID, "state": state},
+		}
+		if inline = c.applyTaskAnnotations(inline); len(inline) > 0 {
+			taskItem["content"] = inline
+		}
+		content = append(content, taskItem)
+		content = append(content, nested...)
+	}
+	return Node{
+		"type":    "taskList",
+		"attrs":   Node{"localId": listID},
+		"content": content,
+	}
+}
+
+// applyTaskAnnotations strips trailing @name(value) annotations from the end
+// of a task item's inline content so they do not show up as literal clutter.
+// A "@due" annotation holding a YYYY-MM-DD date is replaced by an ADF "date"
+// node; all other annotations are dropped. The stripped annotations are
+// reported to the [WithTaskMetadata] callback, if one is configured.
+func (c *converter) applyTaskAnnotations(inline []Node) []Node {
+	if len(inline) == 0 {
+		return inline
+	}
+	last := inline[len(inline)-1]
+	text, ok := last["text"].(string)
+	if last["type"] != "text" || !ok {
+		return inline
+	}
+
+	// Annotations are collected from the end backwards, then put back into
+	// source order.
+	var annotations []TaskAnnotation
+	for {
+		annotation, start, ok := trailingTaskAnnotation(text)
+		if !ok {
+			break
+		}
+		annotations = append(annotations, annotation)
+		text = text[:start]
+	}
+	if len(annotations) == 0 {
+		return inline
+	}
+	slices.Reverse(annotations)
+
+	inline = inline[:len(inline)-1]
+	if text = strings.TrimRight(text, " \t"); text != "" {
+		last["text"] = text
+		inline = append(inline, last)
+	}
+	for _, annotation := range annotations {
+		if annotation.Name != "due" {
+			continue
+		}
+		if due, err := time.Parse(time.DateOnly, annotation.Value); err == nil {
+			if len(inline) > 0 {
+				inline = append(inline, Node{"type": "text", "text": " "})
+			}
+			inline = append(inline, Node{
+				"type":  "date",
+				"attrs": Node{"timestamp": strconv.FormatInt(due.UnixMilli(), 10)},
+			})
+		}
+	}
+
+	if c.cfg.taskMetadata != nil {
+		c.cfg.taskMetadata(plainText(inline), annotations)
+	}
+	return mergeTextNodes(inline)
+}
+
+// plainText concatenates the text of all "text" nodes in nodes.
+func plainText(nodes []Node) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		if text, ok := n["text"].(string); ok {
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,263 @@
+package md2adf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvert_TaskList(t *testing.T) {
+	result := Convert("- [ ] Write docs\n- [x] Ship release")
+	list := result["content"].([]Node)[0]
+	assertType(t, list, "taskList")
+	if id, _ := list["attrs"].(Node)["localId"].(string); id == "" {
+		t.Error("expected taskList to carry a localId")
+	}
+
+	items := list["content"].([]Node)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 task items, got %d", len(items))
+	}
+	states := []string{"TODO", "DONE"}
+	texts := []string{"Write docs", "Ship release"}
+	for i, item := range items {
+		assertType(t, item, "taskItem")
+		attrs := item["attrs"].(Node)
+		if attrs["state"] != states[i] {
+			t.Errorf("item %d: expected state %s, got %v", i, states[i], attrs["state"])
+		}
+		if id, _ := attrs["localId"].(string); id == "" {
+			t.Errorf("item %d: expected a localId", i)
+		}
+		content := item["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("item %d: expected 1 inline node, got %d", i, len(content))
+		}
+		assertText(t, content[0], texts[i])
+	}
+}
+
+func TestConvert_TaskListMixedItems(t *testing.T) {
+	result := Convert("- [ ] task\n- plain item")
+	list := result["content"].([]Node)[0]
+	assertType(t, list, "bulletList")
+
+	first := list["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)
+	assertText(t, first[0], "[ ] task")
+}
+
+func TestConvert_TaskListNesting(t *testing.T) {
+	t.Run("tasks under a bullet item", func(t *testing.T) {
+		list := Convert("- Release\n  - [ ] Tag version\n  - [x] Write notes\n- Other")["content"].([]Node)[0]
+		assertType(t, list, "bulletList")
+
+		items := list["content"].([]Node)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		content := items[0]["content"].([]Node)
+		if len(content) != 2 {
+			t.Fatalf("expected paragraph and taskList, got %d nodes", len(content))
+		}
+		assertText(t, content[0]["content"].([]Node)[0], "Release")
+		tasks := content[1]
+		assertType(t, tasks, "taskList")
+
+		taskItems := tasks["content"].([]Node)
+		if len(taskItems) != 2 {
+			t.Fatalf("expected 2 task items, got %d", len(taskItems))
+		}
+		for i, want := range []struct{ state, text string }{{"TODO", "Tag version"}, {"DONE", "Write notes"}} {
+			assertType(t, taskItems[i], "taskItem")
+			if state := taskItems[i]["attrs"].(Node)["state"]; state != want.state {
+				t.Errorf("task %d: expected state %s, got %v", i, want.state, state)
+			}
+			assertText(t, taskItems[i]["content"].([]Node)[0], want.text)
+		}
+	})
+
+	t.Run("tasks under an ordered item", func(t *testing.T) {
+		item := Convert("1. Step\n   - [ ] Check")["content"].([]Node)[0]["content"].([]Node)[0]
+		assertType(t, item["content"].([]Node)[1], "taskList")
+	})
+
+	// ADF task items hold inline content only, so a task list with a nested
+	// regular list falls back to a regular list with literal checkboxes.
+	t.Run("regular list under a task item", func(t *testing.T) {
+		list := Convert("- [ ] Prepare\n  - agenda\n  - slides\n- [x] Book room")["content"].([]Node)[0]
+		assertType(t, list, "bulletList")
+
+		items := list["content"].([]Node)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(items))
+		}
+		content := items[0]["content"].([]Node)
+		assertText(t, content[0]["content"].([]Node)[0], "[ ] Prepare")
+		assertType(t, content[1], "bulletList")
+		if n := len(content[1]["content"].([]Node)); n != 2 {
+			t.Errorf("expected 2 nested items, got %d", n)
+		}
+		assertText(t, items[1]["content"].([]Node)[0]["content"].([]Node)[0], "[x] Book room")
+	})
+}
+
+func TestConvert_TaskAnnotations(t *testing.T) {
+	var gotText string
+	var gotAnnotations []TaskAnnotation
+	opt := WithTaskMetadata(func(text string, annotations []TaskAnnotation) {
+		gotText = text
+		gotAnnotations = annotations
+	})
+
+	result := Convert("- [ ] Review PR @owner(alice) @due(2024-01-01)", opt)
+	item := result["content"].([]Node)[0]["content"].([]Node)[0]
+	content := item["content"].([]Node)
+
+	if len(content) != 2 {
+		t.Fatalf("expected text + date, got %d nodes", len(content))
+	}
+	assertText(t, content[0], "Review PR ")
+	assertType(t, content[1], "date")
+	if ts := content[1]["attrs"].(Node)["timestamp"]; ts != "1704067200000" {
+		t.Errorf("expected timestamp 1704067200000, got %v", ts)
+	}
+	for _, n := range content {
+		if text, _ := n["text"].(string); strings.Contains(text, "@") {
+			t.Errorf("annotation leaked into text: %q", text)
+		}
+	}
+
+	if gotText != "Review PR " {
+		t.Errorf("expected callback text 'Review PR ', got %q", gotText)
+	}
+	want := []TaskAnnotation{{"owner", "alice"}, {"due", "2024-01-01"}}
+	if fmt.Sprint(gotAnnotations) != fmt.Sprint(want) {
+		t.Errorf("expected annotations %v, got %v", want, gotAnnotations)
+	}
+}
+
+func TestConvert_TaskAnnotationsDropped(t *testing.T) {
+	result := Convert("- [x] Cleanup @owner(bob) @due(not-a-date)")
+	content := result["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)
+
+	if len(content) != 1 {
+		t.Fatalf("expected 1 inline node, got %d", len(content))
+	}
+	assertText(t, content[0], "Cleanup")
+}
+
+func TestConvert_TaskAnnotationsNeedLeadingSpace(t *testing.T) {
+	// An "@" inside a word, as in an email address, does not start an
+	// annotation.
+	for _, md := range []string{"- [ ] buy milk bob@owner(x)", "- [ ] ask a@b.c(y)", "- [ ] note @a(x)@b(y)"} {
+		called := false
+		opt := WithTaskMetadata(func(string, []TaskAnnotation) { called = true })
+		content := Convert(md, opt)["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)
+		if want := strings.TrimPrefix(md, "- [ ] "); plainText(content) != want {
+			t.Errorf("expected text %q kept, got %q", want, plainText(content))
+		}
+		if called {
+			t.Errorf("%q: expected no annotations", md)
+		}
+	}
+
+	result := Convert("- [ ] @owner(x)")
+	if content := result["content"].([]Node)[0]["content"].([]Node)[0]; content["content"] != nil {
+		t.Errorf("expected an annotation at the start of the text stripped, got %v", content["content"])
+	}
+}
+
+func TestConvert_TaskAnnotationsMany(t *testing.T) {
+	// Stripping is linear; 4000 annotations used to take seconds.
+	const n = 4000
+	var gotAnnotations []TaskAnnotation
+	opt := WithTaskMetadata(func(text string, annotations []TaskAnnotation) {
+		gotAnnotations = annotations
+	})
+	result := Convert("- [ ] Task"+strings.Repeat(" @tag(x)", n-1)+" @last(y)", opt)
+	content := result["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)
+	if len(content) != 1 {
+		t.Fatalf("expected 1 inline node, got %d", len(content))
+	}
+	assertText(t, content[0], "Task")
+	if len(gotAnnotations) != n || gotAnnotations[0] != (TaskAnnotation{"tag", "x"}) || gotAnnotations[n-1] != (TaskAnnotation{"last", "y"}) {
+		t.Errorf("expected %d annotations in source order, got %d", n, len(gotAnnotations))
+	}
+}
+
+func TestConvert_LocalIDGenerator(t *testing.T) {
+	next := 0
+	counter := func() string {
+		next++
+		return fmt.Sprintf("id-%d", next)
+	}
+
+	result := Convert("- [ ] one\n  - [ ] nested\n- [x] two", WithLocalIDGenerator(counter))
+	content := result["content"].([]Node)
+	if len(content) != 1 {
+		t.Fatalf("expected 1 node, got %d", len(content))
+	}
+	var ids []string
+	for _, node := range append(collectNodes(content[0], "taskList"), collectNodes(content[0], "taskItem")...) {
+		ids = append(ids, node["attrs"].(Node)["localId"].(string))
+	}
+	// Lists first (outer, nested), then items (one, nested, two).
+	want := []string{"id-1", "id-3", "id-2", "id-4", "id-5"}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("expected ids %v, got %v", want, ids)
+	}
+
+	// The same generator state yields the same document.
+	next = 0
+	again := Convert("- [ ] one\n  - [ ] nested\n- [x] two", WithLocalIDGenerator(counter))
+	first, _ := json.Marshal(result)
+	second, _ := json.Marshal(again)
+	if string(first) != string(second) {
+		t.Errorf("expected identical output\nfirst:  %s\nsecond: %s", first, second)
+	}
+}
+
+func TestConvert_TaskListAsText(t *testing.T) {
+	const input = "- [ ] todo\n- [x] **done**\n  - [ ] nested"
+
+	t.Run("native", func(t *testing.T) {
+		content := Convert(input)["content"].([]Node)
+		assertType(t, content[0], "taskList")
+		if items := collectNodes(content[0], "taskItem"); len(items) != 3 {
+			t.Errorf("expected 3 taskItems, got %d", len(items))
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		content := Convert(input, WithTaskListAsText())["content"].([]Node)
+		if len(content) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(content))
+		}
+		list := content[0]
+		assertType(t, list, "bulletList")
+		if tasks := collectNodes(list, "taskItem"); len(tasks) != 0 {
+			t.Errorf("expected no taskItems, got %d", len(tasks))
+		}
+		items := list["content"].([]Node)
+		if len(items) != 2 {
+			t.Fatalf("expected 2 listItems, got %d", len(items))
+		}
+		assertText(t, items[0]["content"].([]Node)[0]["content"].([]Node)[0], "☐ todo")
+
+		done := items[1]["content"].([]Node)
+		inline := done[0]["content"].([]Node)
+		assertText(t, inline[0], "☑ ")
+		assertText(t, inline[1], "done")
+		assertMarks(t, inline[1], "strong")
+		assertType(t, done[1], "bulletList")
+		assertText(t, done[1]["content"].([]Node)[0]["content"].([]Node)[0]["content"].([]Node)[0], "☐ nested")
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		list := Convert("1. [x] first\n2. [ ] second", WithTaskListAsText())["content"].([]Node)[0]
+		assertType(t, list, "orderedList")
+		second := list["content"].([]Node)[1]
+		assertText(t, second["content"].([]Node)[0]["content"].([]Node)[0], "☐ second")
+	})
+}
@@ -0,0 +1,45 @@
+package md2adf
+
+// Walk visits doc and every node below it depth-first, calling fn on each
+// node before its children. The node fn returns replaces the visited one,
+// and it is the returned node whose children are visited next; returning
+// nil removes the node from its parent. Both the "content" and the "marks"
+// arrays are walked, so fn also sees every mark. Adjacent text nodes may
+// share a mark, so fn should modify marks idempotently.
+//
+// Walk replaces the content and marks arrays of doc in place and returns
+// the new root, which is nil if fn removed it. Use it to post-process converted
+// output, for example to rewrite link URLs or add attributes:
+//
+//	doc = md2adf.Walk(doc, func(n md2adf.Node) md2adf.Node {
+//		if n["type"] == "link" {
+//			attrs := n["attrs"].(md2adf.Node)
+//			attrs["href"] = rewrite(attrs["href"].(string))
+//		}
+//		return n
+//	})
+func Walk(doc Node, fn func(n Node) Node) Node {
+	n := fn(doc)
+	if n == nil {
+		return nil
+	}
+	for _, key := range []string{"content", "marks"} {
+		children, ok := n[key].([]Node)
+		if !ok {
+			continue
+		}
+		kept := make([]Node, 0, len(children))
+		for _, child := range children {
+			if child = Walk(child, fn); child != nil {
+				kept = append(kept, child)
+			}
+		}
+		if key == "marks" && len(kept) == 0 {
+			// A text node without marks omits the key entirely.
+			delete(n, key)
+			continue
+		}
+		n[key] = kept
+	}
+	return n
+}
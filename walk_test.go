@@ -0,0 +1,74 @@
+package md2adf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	t.Run("uppercase heading text", func(t *testing.T) {
+		doc := Convert("# Hello *world*\n\nbody text")
+		doc = Walk(doc, func(n Node) Node {
+			if n["type"] == "heading" {
+				for _, text := range collectNodes(n, "text") {
+					text["text"] = strings.ToUpper(text["text"].(string))
+				}
+			}
+			return n
+		})
+
+		content := doc["content"].([]Node)
+		heading := content[0]["content"].([]Node)
+		assertText(t, heading[0], "HELLO ")
+		assertText(t, heading[1], "WORLD")
+		assertMarks(t, heading[1], "em")
+		assertText(t, content[1]["content"].([]Node)[0], "body text")
+	})
+
+	t.Run("visits marks", func(t *testing.T) {
+		doc := Convert("[a](http://old.example.com/x) and [b](https://other.example.com)")
+		doc = Walk(doc, func(n Node) Node {
+			if n["type"] == "link" {
+				attrs := n["attrs"].(Node)
+				attrs["href"] = strings.Replace(attrs["href"].(string), "http://old.", "https://new.", 1)
+			}
+			return n
+		})
+
+		paraContent := doc["content"].([]Node)[0]["content"].([]Node)
+		if href := paraContent[0]["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://new.example.com/x" {
+			t.Errorf("expected rewritten href, got %v", href)
+		}
+		if href := paraContent[2]["marks"].([]Node)[0]["attrs"].(Node)["href"]; href != "https://other.example.com" {
+			t.Errorf("expected unchanged href, got %v", href)
+		}
+	})
+
+	t.Run("replace and remove", func(t *testing.T) {
+		doc := Convert("keep\n\n---\n\n**bold**")
+		doc = Walk(doc, func(n Node) Node {
+			switch n["type"] {
+			case "rule":
+				return Node{"type": "paragraph", "content": []Node{{"type": "text", "text": "replaced"}}}
+			case "strong":
+				return nil
+			}
+			return n
+		})
+
+		content := doc["content"].([]Node)
+		if len(content) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(content))
+		}
+		assertText(t, content[1]["content"].([]Node)[0], "replaced")
+		bold := content[2]["content"].([]Node)[0]
+		assertText(t, bold, "bold")
+		if _, ok := bold["marks"]; ok {
+			t.Errorf("expected marks key removed, got %v", bold["marks"])
+		}
+
+		if Walk(doc, func(Node) Node { return nil }) != nil {
+			t.Error("expected nil when the root is removed")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package md2adf
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// kindWikiLink is the goldmark node kind for [[Page Name]] links.
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+// wikiLink is an inline [[Page Name]] link. Title holds the trimmed page
+// title between the brackets.
+type wikiLink struct {
+	ast.BaseInline
+	Title string
+}
+
+// Kind implements [ast.Node].
+func (n *wikiLink) Kind() ast.NodeKind { return kindWikiLink }
+
+// Dump implements [ast.Node].
+func (n *wikiLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Title": n.Title}, nil)
+}
+
+// wikiLinkParser parses [[Page Name]] links. The title must be non-empty,
+// fit on one line, and contain no brackets; anything else is left to the
+// regular link parser.
+type wikiLinkParser struct{}
+
+func (p *wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *wikiLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+	inner := line[2 : 2+end]
+	title := bytes.TrimSpace(inner)
+	if len(title) == 0 || bytes.ContainsAny(inner, "[]") {
+		return nil
+	}
+	block.Advance(2 + end + 2)
+	return &wikiLink{Title: string(title)}
+}
+
+// wikiLinkExtension registers the wiki link parser with goldmark. It runs
+// before the link parser, which would otherwise claim the opening bracket.
+type wikiLinkExtension struct{}
+
+func (e wikiLinkExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(util.Prioritized(&wikiLinkParser{}, 199)),
+	)
+}
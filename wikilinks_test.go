@@ -0,0 +1,64 @@
+package md2adf
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestConvert_WikiLinks(t *testing.T) {
+	resolve := func(title string) (string, bool) {
+		if title == "Missing Page" {
+			return "", false
+		}
+		return "https://wiki.example.com/display/" + url.PathEscape(title), true
+	}
+
+	t.Run("resolvable", func(t *testing.T) {
+		result := Convert("See [[Release Notes]] for details.", WithWikiLinks(resolve))
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 3 {
+			t.Fatalf("expected 3 nodes, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "See ")
+		assertText(t, paraContent[1], "Release Notes")
+		assertMarks(t, paraContent[1], "link")
+		href := paraContent[1]["marks"].([]Node)[0]["attrs"].(Node)["href"]
+		if href != "https://wiki.example.com/display/Release%20Notes" {
+			t.Errorf("unexpected href %v", href)
+		}
+		assertText(t, paraContent[2], " for details.")
+	})
+
+	t.Run("unresolvable becomes plain text", func(t *testing.T) {
+		result := Convert("See [[Missing Page]].", WithWikiLinks(resolve))
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+
+		if len(paraContent) != 1 {
+			t.Fatalf("expected 1 node, got %d", len(paraContent))
+		}
+		assertText(t, paraContent[0], "See Missing Page.")
+		assertMarks(t, paraContent[0])
+	})
+
+	t.Run("keeps surrounding marks", func(t *testing.T) {
+		result := Convert("**[[Home]]**", WithWikiLinks(resolve))
+		node := result["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, node, "Home")
+		assertMarks(t, node, "link", "strong")
+	})
+
+	t.Run("regular links unaffected", func(t *testing.T) {
+		result := Convert("[text](https://example.com) and [[ ]]", WithWikiLinks(resolve))
+		paraContent := result["content"].([]Node)[0]["content"].([]Node)
+		assertText(t, paraContent[0], "text")
+		assertMarks(t, paraContent[0], "link")
+		assertText(t, paraContent[1], " and [[ ]]")
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result := Convert("See [[Release Notes]].")
+		node := result["content"].([]Node)[0]["content"].([]Node)[0]
+		assertText(t, node, "See [[Release Notes]].")
+	})
+}